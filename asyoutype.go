@@ -0,0 +1,219 @@
+package main
+
+import "strings"
+
+// AsYouTypeFormatter formats a phone number a digit at a time while it's being typed, like a phone's dialler does.
+// Numbers starting with a + or an international call prefix pick their country from the dial code as soon as it's
+// been typed, anything else is formatted for the default country
+type AsYouTypeFormatter struct {
+	countryCode string // Country of numbers typed without a country code
+	input       string // The + and digits typed so far
+}
+
+// Longest input worth formatting, the longest international call prefix, dial code and national number
+const maxAsYouTypeDigits = 4 + 3 + 17
+
+// Longest input we take including separators like spaces and brackets
+const maxAsYouTypeLength = 64
+
+// AsYouTypeResponse is the number formatted as far as it's been typed
+type AsYouTypeResponse struct {
+	Formatted   string `json:"formatted"`
+	CountryCode string `json:"countryCode"` // Empty until we can tell
+}
+
+func newAsYouTypeFormatter(countryCode string) *AsYouTypeFormatter {
+	return &AsYouTypeFormatter{countryCode: countryCode}
+}
+
+// Adds the next character typed and returns the number formatted so far.
+// Anything other than a digit, or a + at the very start, is ignored
+func (formatter *AsYouTypeFormatter) inputDigit(r rune) string {
+	r = normalizeRune(r)
+	switch {
+	case r >= '0' && r <= '9':
+		formatter.input += string(r)
+	case r == '+' && formatter.input == "":
+		formatter.input = "+"
+	}
+
+	formatted, _ := formatter.format()
+	return formatted
+}
+
+// Starts again with a new number
+func (formatter *AsYouTypeFormatter) clear() {
+	formatter.input = ""
+}
+
+// The country the number looks like it's from so far, empty if we can't tell yet
+func (formatter *AsYouTypeFormatter) country() string {
+	_, country := formatter.format()
+	return country
+}
+
+// Formats what's been typed so far and works out its country
+func (formatter *AsYouTypeFormatter) format() (string, string) {
+	if formatter.input == "" {
+		return "", ""
+	}
+
+	if strings.HasPrefix(formatter.input, "+") {
+		return formatInternationalAsYouType("+", formatter.input[1:])
+	}
+
+	// An international call prefix i.e. 011 or 00 works the same as a +, we keep it the way it was typed
+	if number, found := stripInternationalPrefix(formatter.input, formatter.countryCode); found {
+		prefix := formatter.input[:len(formatter.input)-len(number)+1]
+		return formatInternationalAsYouType(prefix+" ", number[1:])
+	}
+
+	region, exists := lookupRegion(formatter.countryCode)
+	if !exists {
+		return formatter.input, ""
+	}
+
+	// Still typing the international call prefix, or the dial code after it
+	if region.couldBeInternationalPrefix(formatter.input, internationalPrefixLookahead) {
+		return formatter.input, ""
+	}
+
+	// The trunk prefix i.e. the 0 in 020 7946 0018 isn't part of the national number
+	trunkPrefix, nationalNumber := "", formatter.input
+	if region.TrunkPrefix != "" && strings.HasPrefix(nationalNumber, region.TrunkPrefix) {
+		trunkPrefix, nationalNumber = region.TrunkPrefix, nationalNumber[len(region.TrunkPrefix):]
+	}
+
+	return region.formatPartial(nationalNumber, trunkPrefix, false), region.CountryCode
+}
+
+// How many more digits we look ahead for an international call prefix that's partly typed
+const internationalPrefixLookahead = 3
+
+// Whether the digits typed so far are the region's international call prefix, or could be with up to lookahead more
+// digits i.e. 0 and 01 on the way to 011. Once there are as many digits as the prefix pattern has characters they're
+// too long to be part of it, so we stop looking ahead
+func (region *RegionMetadata) couldBeInternationalPrefix(digits string, lookahead int) bool {
+	if region.internationalPrefix == nil {
+		return false
+	}
+	if region.internationalPrefix.MatchString(digits) {
+		return true
+	}
+	if lookahead == 0 || len(digits) >= len(region.InternationalPrefix) {
+		return false
+	}
+
+	for digit := '0'; digit <= '9'; digit++ {
+		if region.couldBeInternationalPrefix(digits+string(digit), lookahead-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// Formats a number typed with a + or international call prefix, the digits start with the dial code
+func formatInternationalAsYouType(prefix, digits string) (string, string) {
+	dialCode, countryCodes, found := dialCodeTrie.longestPrefix(digits)
+	if !found || dialCode == "" {
+		return prefix + digits, ""
+	}
+
+	nationalNumber := digits[len(dialCode):]
+	country := countryCodes[0]
+	if dialCode == "1" {
+		country = resolveNANPCountry(nationalNumber, countryCodes)
+	}
+
+	if nationalNumber == "" {
+		return prefix + dialCode, country
+	}
+
+	region := RegionMetadataMap[country]
+	return prefix + dialCode + " " + region.formatPartial(nationalNumber, "", true), country
+}
+
+// Formats the start of a national number. We pick the format the number would get once it's complete and cut it
+// off after the digits typed so far, i.e. 21256 for the US is (212) 56
+func (region *RegionMetadata) formatPartial(nationalNumber, trunkPrefix string, international bool) string {
+	if nationalNumber == "" {
+		return trunkPrefix
+	}
+
+	format, completed := region.formatForPartial(nationalNumber)
+	if format == nil {
+		return trunkPrefix + nationalNumber
+	}
+
+	// The national format might add the trunk prefix itself i.e. "0$1 $2 $3", so use the international grouping
+	// when the trunk prefix wasn't typed, and add it ourselves when it was but the format doesn't
+	template, lead := format.Format, ""
+	switch {
+	case international:
+		template = format.IntlFormat
+	case trunkPrefix == "" && format.includesTrunkPrefix(region.TrunkPrefix):
+		template = format.IntlFormat
+	case trunkPrefix != "" && !format.includesTrunkPrefix(region.TrunkPrefix):
+		lead = trunkPrefix + " "
+	}
+	if template == "" {
+		template = format.Format
+	}
+	if template == "NA" {
+		return trunkPrefix + nationalNumber
+	}
+
+	// Digits in the template itself (i.e. the trunk prefix) come out before the number's own digits
+	literalDigits := countDigits(groupReference.ReplaceAllString(template, ""))
+	formatted := format.apply(completed, template)
+	return lead + cutAfterDigits(formatted, literalDigits+len(nationalNumber))
+}
+
+// Finds the format for a partly typed national number by filling in the rest of it. Longer numbers are tried
+// first since there could be more to type, and the filler digit has to suit the format's pattern
+func (region *RegionMetadata) formatForPartial(nationalNumber string) (*NumberFormat, string) {
+	for i := len(region.PossibleLengths) - 1; i >= 0; i-- {
+		length := region.PossibleLengths[i]
+		if length < len(nationalNumber) {
+			break
+		}
+		for filler := '0'; filler <= '9'; filler++ {
+			completed := nationalNumber + strings.Repeat(string(filler), length-len(nationalNumber))
+			if format := region.formatFor(completed); format != nil {
+				return format, completed
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+// Whether the national format adds the trunk prefix itself i.e. "0$1 $2 $3"
+func (format *NumberFormat) includesTrunkPrefix(trunkPrefix string) bool {
+	beforeFirstGroup, _, found := strings.Cut(format.Format, "$1")
+	return found && trunkPrefix != "" && strings.Contains(beforeFirstGroup, trunkPrefix)
+}
+
+func countDigits(value string) int {
+	count := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			count++
+		}
+	}
+	return count
+}
+
+// Cuts the formatted number off straight after its nth digit
+func cutAfterDigits(formatted string, n int) string {
+	count := 0
+	for i, r := range formatted {
+		if r >= '0' && r <= '9' {
+			count++
+			if count == n {
+				return formatted[:i+1]
+			}
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAsYouTypeFormatter(t *testing.T) {
+	tests := []struct {
+		name            string
+		countryCode     string
+		input           string
+		expected        []string // Formatted after each character
+		expectedCountry string
+	}{
+		{
+			name:            "US national",
+			countryCode:     "US",
+			input:           "2125690123",
+			expected:        []string{"(2", "(21", "(212", "(212) 5", "(212) 56", "(212) 569", "(212) 569-0", "(212) 569-01", "(212) 569-012", "(212) 569-0123"},
+			expectedCountry: "US",
+		},
+		{
+			name:            "US international",
+			countryCode:     "",
+			input:           "+12125690123",
+			expected:        []string{"+", "+1", "+1 2", "+1 21", "+1 212", "+1 212-5", "+1 212-56", "+1 212-569", "+1 212-569-0", "+1 212-569-01", "+1 212-569-012", "+1 212-569-0123"},
+			expectedCountry: "US",
+		},
+		{
+			name:            "GB national with trunk prefix",
+			countryCode:     "GB",
+			input:           "02079460018",
+			expected:        []string{"0", "02", "020", "020 7", "020 79", "020 794", "020 7946", "020 7946 0", "020 7946 00", "020 7946 001", "020 7946 0018"},
+			expectedCountry: "GB",
+		},
+		{
+			name:            "US trunk prefix isn't in the national format",
+			countryCode:     "US",
+			input:           "12125",
+			expected:        []string{"1", "1 (2", "1 (21", "1 (212", "1 (212) 5"},
+			expectedCountry: "US",
+		},
+		{
+			name:            "International call prefix",
+			countryCode:     "US",
+			input:           "01144207",
+			expected:        []string{"0", "01", "011", "0114", "011 44", "011 44 2", "011 44 20", "011 44 20 7"},
+			expectedCountry: "GB",
+		},
+		{
+			name:            "Two digit international call prefix",
+			countryCode:     "ES",
+			input:           "003491",
+			expected:        []string{"0", "00", "003", "00 34", "00 34 9", "00 34 91"},
+			expectedCountry: "ES",
+		},
+		{
+			name:            "Country changes once the dial code is typed",
+			countryCode:     "US",
+			input:           "+3491",
+			expected:        []string{"+", "+3", "+34", "+34 9", "+34 91"},
+			expectedCountry: "ES",
+		},
+		{
+			name:            "NANP country from the area code",
+			countryCode:     "",
+			input:           "+1416",
+			expected:        []string{"+", "+1", "+1 4", "+1 41", "+1 416"},
+			expectedCountry: "CA",
+		},
+		{
+			name:            "Unknown dial code",
+			countryCode:     "",
+			input:           "+999",
+			expected:        []string{"+", "+9", "+99", "+999"},
+			expectedCountry: "",
+		},
+		{
+			name:            "Separators and letters are ignored",
+			countryCode:     "US",
+			input:           "(212) a5",
+			expected:        []string{"", "(2", "(21", "(212", "(212", "(212", "(212", "(212) 5"},
+			expectedCountry: "US",
+		},
+		{
+			name:            "Too long for any format",
+			countryCode:     "US",
+			input:           "21256901234",
+			expected:        []string{"(2", "(21", "(212", "(212) 5", "(212) 56", "(212) 569", "(212) 569-0", "(212) 569-01", "(212) 569-012", "(212) 569-0123", "21256901234"},
+			expectedCountry: "US",
+		},
+		{
+			name:            "Full-width digits",
+			countryCode:     "JP",
+			input:           "０３１２",
+			expected:        []string{"0", "03", "03-1", "03-12"},
+			expectedCountry: "JP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := newAsYouTypeFormatter(tt.countryCode)
+			var result []string
+			for _, r := range tt.input {
+				result = append(result, formatter.inputDigit(r))
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+			if country := formatter.country(); country != tt.expectedCountry {
+				t.Errorf("Expected country %s, got %s", tt.expectedCountry, country)
+			}
+		})
+	}
+}
+
+func TestAsYouTypeFormatterClear(t *testing.T) {
+	formatter := newAsYouTypeFormatter("US")
+	for _, r := range "+4420" {
+		formatter.inputDigit(r)
+	}
+
+	formatter.clear()
+	if result := formatter.inputDigit('2'); result != "(2" || formatter.country() != "US" {
+		t.Errorf("Expected (2 in US after clearing, got %s in %s", result, formatter.country())
+	}
+}
+
+func TestCouldBeInternationalPrefix(t *testing.T) {
+	tests := []struct {
+		countryCode string
+		digits      string
+		expected    bool
+	}{
+		{"US", "0", true},
+		{"US", "01", true},
+		{"US", "011", true},
+		{"US", "0114", true},
+		{"US", "02", false},
+		{"US", "2125", false},
+		{"ES", "0", true},
+		{"ES", "003", true},
+		{"ES", "09", false},
+	}
+
+	for _, tt := range tests {
+		region := RegionMetadataMap[tt.countryCode]
+		if result := region.couldBeInternationalPrefix(tt.digits, internationalPrefixLookahead); result != tt.expected {
+			t.Errorf("couldBeInternationalPrefix(%s, %s) = %v, expected %v", tt.countryCode, tt.digits, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "sync"
+
+// BatchItem is one number in a POST /v1/phone-numbers:batch request
+type BatchItem struct {
+	PhoneNumber string `json:"phoneNumber"`
+	CountryCode string `json:"countryCode"`
+}
+
+// Most numbers we'll take in one batch request, bigger lists should be split up. Set with -batch-max-items
+var maxBatchItems = 1000
+
+// Room for each item in the request body, far more than a phone number and country code need even with whitespace
+const maxBatchItemBytes = 1024
+
+// How many numbers in a batch are parsed at the same time. Set with -batch-workers
+var batchWorkers = 8
+
+// Parses every item in the batch on a pool of workers. Each result is a *PhoneNumberResponse or an *ErrorResponse
+// and they come back in the same order as the items
+func parseBatch(items []BatchItem, options ParseOptions) []any {
+	results := make([]any, len(items))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = parseBatchItem(items[i], options)
+			}
+		}()
+	}
+
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func parseBatchItem(item BatchItem, options ParseOptions) any {
+	if item.PhoneNumber == "" {
+		return &ErrorResponse{
+			Error: map[string]string{"phoneNumber": "required value is missing"},
+		}
+	}
+
+	result, errorResp := parsePhoneNumberWithOptions(item.PhoneNumber, item.CountryCode, options)
+	if errorResp != nil {
+		return errorResp
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseBatch(t *testing.T) {
+	items := []BatchItem{
+		{PhoneNumber: "+12125690123"},
+		{PhoneNumber: "631 311 8150", CountryCode: "MX"},
+		{PhoneNumber: "631 311 8150"},
+		{PhoneNumber: ""},
+		{PhoneNumber: "020 7946 0018", CountryCode: "GB"},
+	}
+
+	results := parseBatch(items, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+	if len(results) != len(items) {
+		t.Fatalf("Expected %d results, got %d", len(items), len(results))
+	}
+
+	expected := []struct {
+		phoneNumber string
+		errorKey    string
+		errorValue  string
+	}{
+		{"+12125690123", "", ""},
+		{"+526313118150", "", ""},
+		{"631 311 8150", "countryCode", "required value is missing"},
+		{"", "phoneNumber", "required value is missing"},
+		{"+442079460018", "", ""},
+	}
+
+	for i, want := range expected {
+		switch result := results[i].(type) {
+		case *PhoneNumberResponse:
+			if want.errorKey != "" {
+				t.Errorf("Item %d: expected error %s, got %s", i, want.errorKey, result.PhoneNumber)
+			} else if result.PhoneNumber != want.phoneNumber {
+				t.Errorf("Item %d: expected %s, got %s", i, want.phoneNumber, result.PhoneNumber)
+			}
+		case *ErrorResponse:
+			if result.Error[want.errorKey] != want.errorValue || result.PhoneNumber != want.phoneNumber {
+				t.Errorf("Item %d: expected error %s: %s for %q, got %v for %q", i, want.errorKey, want.errorValue, want.phoneNumber, result.Error, result.PhoneNumber)
+			}
+		default:
+			t.Errorf("Item %d: unexpected result %T", i, result)
+		}
+	}
+}
+
+func TestParseBatchKeepsOrder(t *testing.T) {
+	// More items than workers so they finish out of order
+	var items []BatchItem
+	for i := 0; i < 100; i++ {
+		items = append(items, BatchItem{PhoneNumber: fmt.Sprintf("+1212569%04d", i)})
+	}
+
+	results := parseBatch(items, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+	for i, result := range results {
+		response, ok := result.(*PhoneNumberResponse)
+		if !ok {
+			t.Fatalf("Item %d: expected a phone number, got %+v", i, result)
+		}
+		if expected := fmt.Sprintf("+1212569%04d", i); response.PhoneNumber != expected {
+			t.Errorf("Item %d: expected %s, got %s", i, expected, response.PhoneNumber)
+		}
+	}
+}
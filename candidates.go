@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Candidate is one possible answer for a number that can be read more than one way
+type Candidate struct {
+	PhoneNumber      string  `json:"phoneNumber"`
+	CountryCode      string  `json:"countryCode"`
+	AreaCode         string  `json:"areaCode"`
+	LocalPhoneNumber string  `json:"localPhoneNumber"`
+	Confidence       float64 `json:"confidence"`
+}
+
+// How much more we trust a national reading than a plus-less international one, the caller told us the country
+const nationalReadingWeight = 2.0
+
+// How much a built-in NANP country counts when nothing but the shared NANP pattern says the number could be there.
+// The area code table would have told us if it was, so it's a long shot next to the US
+const sharedNANPPatternWeight = 0.05
+
+// Ranks every country and split the valid readings of a number could belong to, most likely first.
+// Returns nothing when the number can only be read one way
+func rankCandidates(interpretations []interpretation) []Candidate {
+	var valid []interpretation
+	totalWeight := 0.0
+	for _, reading := range interpretations {
+		if reading.valid {
+			valid = append(valid, reading)
+			totalWeight += readingWeight(reading)
+		}
+	}
+
+	var candidates []Candidate
+	for _, reading := range valid {
+		share := readingWeight(reading) / totalWeight
+		countries := candidateCountries(reading)
+
+		// The main country for the calling code is our best guess so it counts double
+		countryWeights := make([]float64, len(countries))
+		countryTotal := 0.0
+		for i, countryCode := range countries {
+			region, _ := lookupRegion(countryCode)
+			switch {
+			case region.MainCountryForCode:
+				countryWeights[i] = 2
+			case sharesNANPPattern(region):
+				countryWeights[i] = sharedNANPPatternWeight
+			default:
+				countryWeights[i] = 1
+			}
+			countryTotal += countryWeights[i]
+		}
+
+		for i, countryCode := range countries {
+			region, _ := lookupRegion(countryCode)
+			areaCode, localNumber := region.splitAreaCode(reading.nationalNumber)
+			candidates = append(candidates, Candidate{
+				PhoneNumber:      reading.e164(),
+				CountryCode:      region.CountryCode,
+				AreaCode:         areaCode,
+				LocalPhoneNumber: localNumber,
+				Confidence:       math.Round(share*countryWeights[i]/countryTotal*100) / 100,
+			})
+		}
+	}
+
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	return candidates
+}
+
+// Whether the region is one of the built-in NANP countries that only has the shared NANP pattern
+func sharesNANPPattern(region *RegionMetadata) bool {
+	return region.NationalNumberPattern == nanpPattern && !region.MainCountryForCode
+}
+
+func readingWeight(reading interpretation) float64 {
+	if reading.international {
+		return 1
+	}
+	return nationalReadingWeight
+}
+
+// The countries a reading could belong to. A national reading is for the country we were given, an international
+// one could be any country sharing the calling code that accepts the number
+func candidateCountries(reading interpretation) []string {
+	if !reading.international {
+		return []string{reading.countryCode}
+	}
+
+	_, countryCodes, found := dialCodeTrie.longestPrefix(reading.dialCode)
+	if !found || len(countryCodes) <= 1 {
+		return []string{reading.countryCode}
+	}
+
+	// The NANP area code table tells us exactly which country the number is in
+	if reading.dialCode == "1" && len(reading.nationalNumber) >= 3 {
+		if _, exists := nanpAreaCodes[reading.nationalNumber[:3]]; exists {
+			return []string{reading.countryCode}
+		}
+	}
+
+	// The built-in NANP countries all share one pattern so an area code missing from the table could be in any of
+	// them, they're ranked well below the US. Metadata loaded from libphonenumber has a pattern per country instead
+	var countries []string
+	for _, countryCode := range countryCodes {
+		if RegionMetadataMap[countryCode].validateNationalNumber(reading.nationalNumber) == "" {
+			countries = append(countries, countryCode)
+		}
+	}
+
+	if len(countries) == 0 {
+		return []string{reading.countryCode}
+	}
+
+	return countries
+}
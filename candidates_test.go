@@ -0,0 +1,110 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankCandidates(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		countryCode string
+		expected    []Candidate
+	}{
+		{
+			name:        "Only one reading",
+			phoneNumber: "+34915872200",
+			countryCode: "",
+			expected:    nil,
+		},
+		{
+			name:        "NANP country known from the area code",
+			phoneNumber: "+14165550123",
+			countryCode: "",
+			expected:    nil,
+		},
+		{
+			name:        "Plus-less digits valid both ways",
+			phoneNumber: "4930123456",
+			countryCode: "DE",
+			expected: []Candidate{
+				{PhoneNumber: "+494930123456", CountryCode: "DE", AreaCode: "4930", LocalPhoneNumber: "123456", Confidence: 0.67},
+				{PhoneNumber: "+4930123456", CountryCode: "DE", AreaCode: "30", LocalPhoneNumber: "123456", Confidence: 0.33},
+			},
+		},
+		{
+			name:        "Invalid reading is left out",
+			phoneNumber: "1632960001",
+			countryCode: "GB",
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := rankCandidates(findInterpretations(tt.phoneNumber, tt.countryCode))
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("rankCandidates(%s, %s) = %+v, expected %+v", tt.phoneNumber, tt.countryCode, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRankCandidatesSharedDialCode(t *testing.T) {
+	// Loaded metadata has a pattern per NANP country, pretend Canada's also covers an area code we have no data for
+	regions := map[string]*RegionMetadata{
+		"US": RegionMetadataMap["US"],
+		"CA": nanpRegion("CA"),
+		"JM": nanpRegion("JM"),
+	}
+	regions["CA"].NationalNumberPattern = `(?:212|416)[2-9]\d{6}`
+	if err := regions["CA"].prepare(); err != nil {
+		t.Fatalf("Could not prepare CA: %v", err)
+	}
+
+	builtin := RegionMetadataMap
+	installRegionMetadata(regions)
+	defer installRegionMetadata(builtin)
+
+	// JM only has the shared NANP pattern so it's a long shot
+	expected := []Candidate{
+		{PhoneNumber: "+12125690123", CountryCode: "US", AreaCode: "212", LocalPhoneNumber: "5690123", Confidence: 0.66},
+		{PhoneNumber: "+12125690123", CountryCode: "CA", AreaCode: "212", LocalPhoneNumber: "5690123", Confidence: 0.33},
+		{PhoneNumber: "+12125690123", CountryCode: "JM", AreaCode: "212", LocalPhoneNumber: "5690123", Confidence: 0.02},
+	}
+	result := rankCandidates(findInterpretations("+12125690123", ""))
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+
+	// The area code table still settles numbers it knows about
+	if result := rankCandidates(findInterpretations("+14165550123", "")); result != nil {
+		t.Errorf("Expected no candidates for a known area code, got %+v", result)
+	}
+}
+
+func TestRankCandidatesBuiltinNANP(t *testing.T) {
+	// Without the metadata file every NANP country shares a pattern, so an area code that isn't in the table could be
+	// in any of them. The US is still by far the most likely
+	_, nanpCountries, _ := dialCodeTrie.longestPrefix("1")
+
+	result := rankCandidates(findInterpretations("+12125690123", ""))
+	if len(result) != len(nanpCountries) {
+		t.Fatalf("Expected %d candidates, got %d: %+v", len(nanpCountries), len(result), result)
+	}
+
+	if result[0].CountryCode != "US" || result[0].Confidence != 0.63 {
+		t.Errorf("Expected US first with 0.63, got %+v", result[0])
+	}
+	for _, candidate := range result[1:] {
+		if candidate.Confidence != 0.02 || candidate.PhoneNumber != "+12125690123" || candidate.AreaCode != "212" {
+			t.Errorf("Expected a long shot for +12125690123, got %+v", candidate)
+		}
+	}
+
+	// The area code table still settles numbers it knows about
+	if result := rankCandidates(findInterpretations("+14165550123", "")); result != nil {
+		t.Errorf("Expected no candidates for a known area code, got %+v", result)
+	}
+}
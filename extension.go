@@ -0,0 +1,30 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Extension markers at the end of a number i.e. x123, ext. 45, extension 7 or ;ext=42 from a tel: URI
+var extensionPattern = regexp.MustCompile(`(?i)(?:;ext=|x\.?|ext\.?|extn\.?|extension)\s*([0-9]{1,10})$`)
+
+// Splits an extension off the end of a number. Returns the number unchanged and no extension if there isn't one
+func splitExtension(phoneNumber string) (string, string) {
+	// Every marker has an x in it, most numbers don't so there's no need to run the pattern
+	if !strings.ContainsAny(phoneNumber, "xX") {
+		return phoneNumber, ""
+	}
+
+	match := extensionPattern.FindStringSubmatchIndex(phoneNumber)
+	if match == nil {
+		return phoneNumber, ""
+	}
+
+	// Whatever is before the marker still has to look like a number, "x123" on its own isn't an extension
+	number := strings.TrimRight(phoneNumber[:match[0]], " ")
+	if number == "" {
+		return phoneNumber, ""
+	}
+
+	return number, phoneNumber[match[2]:match[3]]
+}
@@ -0,0 +1,104 @@
+package main
+
+import "testing"
+
+func TestSplitExtension(t *testing.T) {
+	tests := []struct {
+		name              string
+		phoneNumber       string
+		expectedNumber    string
+		expectedExtension string
+	}{
+		{"No extension", "+1 212 569 0123", "+1 212 569 0123", ""},
+		{"x marker", "+1 212 569 0123 x123", "+1 212 569 0123", "123"},
+		{"x marker without space", "2125690123x123", "2125690123", "123"},
+		{"Upper case X", "2125690123 X 9", "2125690123", "9"},
+		{"ext. marker", "+44 20 7946 0018 ext. 45", "+44 20 7946 0018", "45"},
+		{"ext marker", "+44 20 7946 0018 ext 45", "+44 20 7946 0018", "45"},
+		{"extension marker", "+44 20 7946 0018 extension 7", "+44 20 7946 0018", "7"},
+		{"tel URI marker", "+1-212-569-0123;ext=42", "+1-212-569-0123", "42"},
+		{"Marker without digits", "+1 212 569 0123 ext", "+1 212 569 0123 ext", ""},
+		{"Extension on its own", "x123", "x123", ""},
+		{"Extension too long", "2125690123 x12345678901", "2125690123 x12345678901", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, extension := splitExtension(tt.phoneNumber)
+			if number != tt.expectedNumber || extension != tt.expectedExtension {
+				t.Errorf("splitExtension(%s) = (%s, %s), expected (%s, %s)",
+					tt.phoneNumber, number, extension, tt.expectedNumber, tt.expectedExtension)
+			}
+		})
+	}
+}
+
+func TestParsePhoneNumberWithExtension(t *testing.T) {
+	tests := []struct {
+		name              string
+		phoneNumber       string
+		countryCode       string
+		options           ParseOptions
+		expectedNumber    string
+		expectedExtension string
+		expectedRFC3966   string
+	}{
+		{
+			name:              "Strict with x marker",
+			phoneNumber:       "+1 212 5690123 x123",
+			options:           ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectedNumber:    "+12125690123",
+			expectedExtension: "123",
+			expectedRFC3966:   "tel:+1-212-569-0123;ext=123",
+		},
+		{
+			name:              "National number with ext.",
+			phoneNumber:       "020 7946 0018 ext. 45",
+			countryCode:       "GB",
+			options:           ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectedNumber:    "+442079460018",
+			expectedExtension: "45",
+			expectedRFC3966:   "tel:+44-20-7946-0018;ext=45",
+		},
+		{
+			name:              "Lenient with tel URI marker",
+			phoneNumber:       "(212) 569-0123;ext=42",
+			countryCode:       "US",
+			options:           ParseOptions{Mode: ModeLenient, CountryCodeConflict: ConflictWarn},
+			expectedNumber:    "+12125690123",
+			expectedExtension: "42",
+			expectedRFC3966:   "tel:+1-212-569-0123;ext=42",
+		},
+		{
+			name:              "No extension",
+			phoneNumber:       "+12125690123",
+			options:           ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectedNumber:    "+12125690123",
+			expectedExtension: "",
+			expectedRFC3966:   "tel:+1-212-569-0123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errorResp := parsePhoneNumberWithOptions(tt.phoneNumber, tt.countryCode, tt.options)
+			if errorResp != nil {
+				t.Fatalf("Expected no error but got %v", errorResp.Error)
+			}
+			if result.PhoneNumber != tt.expectedNumber {
+				t.Errorf("Expected phoneNumber %s, got %s", tt.expectedNumber, result.PhoneNumber)
+			}
+			if result.Extension != tt.expectedExtension {
+				t.Errorf("Expected extension %s, got %s", tt.expectedExtension, result.Extension)
+			}
+			if result.Formats.RFC3966 != tt.expectedRFC3966 {
+				t.Errorf("Expected rfc3966 %s, got %s", tt.expectedRFC3966, result.Formats.RFC3966)
+			}
+		})
+	}
+
+	// An extension doesn't make an invalid number valid
+	if _, errorResp := parsePhoneNumber("+1 212 56A 0123 x123", ""); errorResp == nil || errorResp.Error["phoneNumber"] != "invalid format" {
+		t.Errorf("Expected invalid format error, got %v", errorResp)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// ExtractRequest is the body of POST /v1/phone-numbers/extract
+type ExtractRequest struct {
+	Text        string `json:"text"`
+	CountryCode string `json:"countryCode"` // Country of the numbers written without a country code
+}
+
+type ExtractResponse struct {
+	PhoneNumbers []ExtractedPhoneNumber `json:"phoneNumbers"`
+}
+
+// ExtractedPhoneNumber is a phone number found in text along with where it was found
+type ExtractedPhoneNumber struct {
+	Start int    `json:"start"` // Character offset of the first character of the number
+	End   int    `json:"end"`   // Character offset just after the last character of the number
+	Text  string `json:"text"`  // The number as it was written
+	*PhoneNumberResponse
+}
+
+// Biggest request body we'll read, plenty for an email or support ticket
+const maxExtractBodyBytes = 64 * 1024
+
+// Anything that looks like it could be a phone number, digits with the usual separators and maybe an extension.
+// Whether it really is one is up to the parser
+var phoneNumberCandidate = regexp.MustCompile(`(?i)(?:\+|\()?[0-9][0-9 ()./-]{4,}[0-9](?:\s*(?:x|ext\.?|extn\.?|extension)\s*[0-9]{1,10})?`)
+
+// Finds every valid phone number in the text. Numbers are parsed in lenient mode since people write them all
+// sorts of ways, anything that doesn't parse is skipped
+func extractPhoneNumbers(text, countryCode string) []ExtractedPhoneNumber {
+	// Normalise one character at a time so offsets in the normalised text are the same as in the original
+	original := []rune(text)
+	normalized := make([]rune, len(original))
+	for i, r := range original {
+		if normalized[i] = normalizeRune(r); normalized[i] == -1 {
+			normalized[i] = ' '
+		}
+	}
+	normalizedText := string(normalized)
+
+	// countryCode is only for numbers written without one, numbers from other countries aren't a conflict
+	options := ParseOptions{Mode: ModeLenient, CountryCodeConflict: ConflictIgnore}
+	extracted := []ExtractedPhoneNumber{}
+
+	// The matches come in order so we can count characters as we go instead of from the start each time
+	byteOffset, runeOffset := 0, 0
+	for _, match := range phoneNumberCandidate.FindAllStringIndex(normalizedText, -1) {
+		start := runeOffset + utf8.RuneCountInString(normalizedText[byteOffset:match[0]])
+		end := start + utf8.RuneCountInString(normalizedText[match[0]:match[1]])
+		byteOffset, runeOffset = match[1], end
+
+		extracted = append(extracted, extractFromCandidate(original, normalized, start, end, countryCode, options)...)
+	}
+
+	return extracted
+}
+
+// Most digits worth trying to parse at once, an international call prefix, the longest E.164 number and an extension
+const maxCandidateDigits = 30
+
+// Most space separated parts worth trying to parse at once, i.e. +33 1 23 45 67 89 ext. 12 is 8
+const maxCandidateParts = 10
+
+// Parses a candidate, which could be several numbers written one after another i.e. 212 569 0123 415 555 0100.
+// From each space separated part we take the longest run of parts that parses, trying the whole candidate first.
+// Runs are never longer than maxCandidateDigits digits or maxCandidateParts parts so a long candidate doesn't take
+// long to go through
+func extractFromCandidate(original, normalized []rune, start, end int, countryCode string, options ParseOptions) []ExtractedPhoneNumber {
+	type part struct {
+		start, end, digits int
+	}
+
+	var parts []part
+	for i := start; i < end; {
+		for i < end && normalized[i] == ' ' {
+			i++
+		}
+		partStart, digits := i, 0
+		for i < end && normalized[i] != ' ' {
+			if normalized[i] >= '0' && normalized[i] <= '9' {
+				digits++
+			}
+			i++
+		}
+		if i > partStart {
+			parts = append(parts, part{partStart, i, digits})
+		}
+	}
+
+	var extracted []ExtractedPhoneNumber
+	for first := 0; first < len(parts); first++ {
+		// A number starts with a digit, a + or a bracket, punctuation on its own like the - in 212 569 0123 - 415 555 0100 doesn't
+		if r := normalized[parts[first].start]; r != '+' && r != '(' && (r < '0' || r > '9') {
+			continue
+		}
+
+		// Part of a longer word or code i.e. an order number like AB12345678
+		if parts[first].start > 0 && isAlphanumeric(normalized[parts[first].start-1]) {
+			continue
+		}
+
+		// The furthest part the run can reach
+		furthest, digits := first-1, 0
+		for furthest+1 < len(parts) && furthest+1-first < maxCandidateParts && digits+parts[furthest+1].digits <= maxCandidateDigits {
+			furthest++
+			digits += parts[furthest].digits
+		}
+
+		for last := furthest; last >= first; last-- {
+			spanStart, spanEnd := parts[first].start, parts[last].end
+			if r := normalized[spanEnd-1]; r < '0' || r > '9' {
+				continue
+			}
+			if spanEnd < len(normalized) && isAlphanumeric(normalized[spanEnd]) {
+				continue
+			}
+
+			raw := string(original[spanStart:spanEnd])
+			result, errorResp := parsePhoneNumberWithOptions(raw, countryCode, options)
+			if errorResp != nil {
+				continue
+			}
+
+			extracted = append(extracted, ExtractedPhoneNumber{
+				Start:               spanStart,
+				End:                 spanEnd,
+				Text:                raw,
+				PhoneNumberResponse: result,
+			})
+			first = last
+			break
+		}
+	}
+
+	return extracted
+}
+
+// Only ASCII counts, Japanese or Chinese text often runs straight into a number without a space
+func isAlphanumeric(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractPhoneNumbers(t *testing.T) {
+	type found struct {
+		start       int
+		end         int
+		text        string
+		phoneNumber string
+	}
+
+	tests := []struct {
+		name        string
+		text        string
+		countryCode string
+		expected    []found
+	}{
+		{
+			name:        "Single number",
+			text:        "Call me on (212) 569-0123 tomorrow",
+			countryCode: "US",
+			expected:    []found{{11, 25, "(212) 569-0123", "+12125690123"}},
+		},
+		{
+			name:        "Several numbers",
+			text:        "Office +44 20 7946 0018, mobile +1 212.569.0123",
+			countryCode: "",
+			expected: []found{
+				{7, 23, "+44 20 7946 0018", "+442079460018"},
+				{32, 47, "+1 212.569.0123", "+12125690123"},
+			},
+		},
+		{
+			name:        "Number with an extension",
+			text:        "Reception: 212-569-0123 ext. 45.",
+			countryCode: "US",
+			expected:    []found{{11, 31, "212-569-0123 ext. 45", "+12125690123"}},
+		},
+		{
+			name:        "Offsets count characters not bytes",
+			text:        "電話番号：０３－１２３４－５６７８です",
+			countryCode: "JP",
+			expected:    []found{{5, 17, "０３－１２３４－５６７８", "+81312345678"}},
+		},
+		{
+			name:        "Full-width number",
+			text:        "電話：＋８１ ３ １２３４ ５６７８",
+			countryCode: "",
+			expected:    []found{{3, 18, "＋８１ ３ １２３４ ５６７８", "+81312345678"}},
+		},
+		{
+			name:        "Numbers next to each other",
+			text:        "Numbers: 212 569 0123 415 555 0100",
+			countryCode: "US",
+			expected: []found{
+				{9, 21, "212 569 0123", "+12125690123"},
+				{22, 34, "415 555 0100", "+14155550100"},
+			},
+		},
+		{
+			name:        "Numbers separated by a dash",
+			text:        "(212) 569-0123 - (415) 555-0100",
+			countryCode: "US",
+			expected: []found{
+				{0, 14, "(212) 569-0123", "+12125690123"},
+				{17, 31, "(415) 555-0100", "+14155550100"},
+			},
+		},
+		{
+			name:        "Invalid numbers are skipped",
+			text:        "Order 2024-01-15 came to 12345 dollars",
+			countryCode: "US",
+			expected:    nil,
+		},
+		{
+			name:        "Part of a code",
+			text:        "Reference AB2125690123",
+			countryCode: "US",
+			expected:    nil,
+		},
+		{
+			name:        "Numbers after a code",
+			text:        "ref AB12345678. 212 569 0123 415 555 0100",
+			countryCode: "US",
+			expected: []found{
+				{16, 28, "212 569 0123", "+12125690123"},
+				{29, 41, "415 555 0100", "+14155550100"},
+			},
+		},
+		{
+			name:        "Number before a word",
+			text:        "Call 212 569 0123abc",
+			countryCode: "US",
+			expected:    nil,
+		},
+		{
+			name:        "No numbers",
+			text:        "Nothing to see here",
+			countryCode: "US",
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractPhoneNumbers(tt.text, tt.countryCode)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Expected %d phone numbers, got %d: %+v", len(tt.expected), len(result), result)
+			}
+			for i, expected := range tt.expected {
+				got := result[i]
+				if got.Start != expected.start || got.End != expected.end || got.Text != expected.text || got.PhoneNumber != expected.phoneNumber {
+					t.Errorf("Expected %d-%d %q %s, got %d-%d %q %s", expected.start, expected.end, expected.text, expected.phoneNumber,
+						got.Start, got.End, got.Text, got.PhoneNumber)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractPhoneNumbersDefaultCountry(t *testing.T) {
+	// The country code is only a default, numbers from other countries don't get a conflict warning
+	result := extractPhoneNumbers("London +44 20 7946 0018 or Toronto 416 555 0123", "US")
+
+	expected := []string{"+442079460018", "+14165550123"}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %d phone numbers, got %d: %+v", len(expected), len(result), result)
+	}
+	for i, phoneNumber := range expected {
+		if result[i].PhoneNumber != phoneNumber {
+			t.Errorf("Expected %s, got %s", phoneNumber, result[i].PhoneNumber)
+		}
+		if len(result[i].Warnings) != 0 {
+			t.Errorf("Expected no warnings for %s, got %v", phoneNumber, result[i].Warnings)
+		}
+	}
+}
+
+func TestExtractPhoneNumbersLongCandidate(t *testing.T) {
+	// Thousands of spaced out digits are one candidate that never parses, it mustn't take long to give up on
+	text := strings.Repeat("1 ", 4000)
+
+	started := time.Now()
+	if result := extractPhoneNumbers(text, "US"); len(result) != 0 {
+		t.Errorf("Expected no phone numbers, got %+v", result)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected a long candidate to be quick, took %v", elapsed)
+	}
+}
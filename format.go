@@ -0,0 +1,61 @@
+package main
+
+import (
+	"regexp"
+)
+
+// PhoneNumberFormats is the number rendered for people and for tel: links
+type PhoneNumberFormats struct {
+	International string `json:"international"` // i.e. +1 212-569-0123
+	National      string `json:"national"`      // i.e. (212) 569-0123
+	RFC3966       string `json:"rfc3966"`       // i.e. tel:+1-212-569-0123
+}
+
+// Turns $1 into ${1} so a digit or letter after the group reference isn't read as part of its name
+var groupReference = regexp.MustCompile(`\$(\d)`)
+
+// Anything between the digits of a formatted number
+var formattingSeparators = regexp.MustCompile(`[^0-9+]+`)
+
+// Formats a valid national number using the region's formatting rules
+// Numbers none of the formats apply to fall back to area code and local number separated by a space
+func (region *RegionMetadata) formatNumber(nationalNumber string) PhoneNumberFormats {
+	var national, international string
+
+	if format := region.formatFor(nationalNumber); format != nil {
+		national = format.apply(nationalNumber, format.Format)
+
+		// NA means the number can't be dialled from abroad so there's no grouping for it
+		intlFormat := format.IntlFormat
+		if intlFormat == "" {
+			intlFormat = format.Format
+		}
+		if intlFormat == "NA" {
+			international = nationalNumber
+		} else {
+			international = format.apply(nationalNumber, intlFormat)
+		}
+	} else {
+		areaCode, localNumber := region.splitAreaCode(nationalNumber)
+		if areaCode == "" {
+			national, international = nationalNumber, nationalNumber
+		} else {
+			national = region.TrunkPrefix + areaCode + " " + localNumber
+			international = areaCode + " " + localNumber
+		}
+	}
+
+	international = "+" + region.DialCode + " " + international
+
+	return PhoneNumberFormats{
+		International: international,
+		National:      national,
+		RFC3966:       "tel:" + formattingSeparators.ReplaceAllString(international, "-"),
+	}
+}
+
+// Groups the national number with the format's pattern and lays the groups out with the template
+func (format *NumberFormat) apply(nationalNumber, template string) string {
+	template = groupReference.ReplaceAllString(template, "$${$1}")
+	return format.pattern.ReplaceAllString(nationalNumber, template)
+}
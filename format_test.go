@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		name           string
+		countryCode    string
+		nationalNumber string
+		expected       PhoneNumberFormats
+	}{
+		{"US", "US", "2125690123", PhoneNumberFormats{
+			International: "+1 212-569-0123",
+			National:      "(212) 569-0123",
+			RFC3966:       "tel:+1-212-569-0123",
+		}},
+		{"Jamaica", "JM", "8765550123", PhoneNumberFormats{
+			International: "+1 876-555-0123",
+			National:      "(876) 555-0123",
+			RFC3966:       "tel:+1-876-555-0123",
+		}},
+		{"Mexico City", "MX", "5512345678", PhoneNumberFormats{
+			International: "+52 55 1234 5678",
+			National:      "55 1234 5678",
+			RFC3966:       "tel:+52-55-1234-5678",
+		}},
+		{"Spain fixed line", "ES", "915872200", PhoneNumberFormats{
+			International: "+34 915 87 22 00",
+			National:      "915 87 22 00",
+			RFC3966:       "tel:+34-915-87-22-00",
+		}},
+		{"Spain mobile", "ES", "612345678", PhoneNumberFormats{
+			International: "+34 612 345 678",
+			National:      "612 345 678",
+			RFC3966:       "tel:+34-612-345-678",
+		}},
+		{"London", "GB", "2079460018", PhoneNumberFormats{
+			International: "+44 20 7946 0018",
+			National:      "020 7946 0018",
+			RFC3966:       "tel:+44-20-7946-0018",
+		}},
+		{"UK mobile", "GB", "7400123456", PhoneNumberFormats{
+			International: "+44 7400 123456",
+			National:      "07400 123456",
+			RFC3966:       "tel:+44-7400-123456",
+		}},
+		{"France", "FR", "612345678", PhoneNumberFormats{
+			International: "+33 6 12 34 56 78",
+			National:      "06 12 34 56 78",
+			RFC3966:       "tel:+33-6-12-34-56-78",
+		}},
+		{"Berlin", "DE", "301234567", PhoneNumberFormats{
+			International: "+49 30 1234567",
+			National:      "030 1234567",
+			RFC3966:       "tel:+49-30-1234567",
+		}},
+		{"Milan", "IT", "0212345678", PhoneNumberFormats{
+			International: "+39 02 1234 5678",
+			National:      "02 1234 5678",
+			RFC3966:       "tel:+39-02-1234-5678",
+		}},
+		{"Tokyo", "JP", "312345678", PhoneNumberFormats{
+			International: "+81 3-1234-5678",
+			National:      "03-1234-5678",
+			RFC3966:       "tel:+81-3-1234-5678",
+		}},
+		{"No matching format", "DE", "1234", PhoneNumberFormats{
+			International: "+49 1234",
+			National:      "1234",
+			RFC3966:       "tel:+49-1234",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, _ := lookupRegion(tt.countryCode)
+			result := region.formatNumber(tt.nationalNumber)
+			if result != tt.expected {
+				t.Errorf("formatNumber(%s) = %+v, expected %+v", tt.nationalNumber, result, tt.expected)
+			}
+		})
+	}
+}
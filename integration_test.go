@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +17,9 @@ func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/v1/phone-numbers", phoneNumberHandler)
+	r.POST("/v1/phone-numbers/extract", extractHandler)
+	r.GET("/v1/phone-numbers/format-as-you-type", formatAsYouTypeHandler)
+	r.POST("/v1/phone-numbers:method", phoneNumbersMethodHandler)
 	return r
 }
 
@@ -41,6 +47,11 @@ func TestPhoneNumberHandlerIntegration(t *testing.T) {
 				CountryCode:      "US",
 				AreaCode:         "212",
 				LocalPhoneNumber: "5690123",
+				Formats: PhoneNumberFormats{
+					International: "+1 212-569-0123",
+					National:      "(212) 569-0123",
+					RFC3966:       "tel:+1-212-569-0123",
+				},
 			},
 		},
 		{
@@ -67,6 +78,7 @@ func TestPhoneNumberHandlerIntegration(t *testing.T) {
 				CountryCode:      "ES",
 				AreaCode:         "915",
 				LocalPhoneNumber: "872200",
+				NumberType:       "FIXED_LINE",
 			},
 		},
 		{
@@ -83,13 +95,31 @@ func TestPhoneNumberHandlerIntegration(t *testing.T) {
 			},
 		},
 		{
-			name:           "Invalid - too many spaces",
-			phoneNumber:    "351 21 094 2000",
+			name:           "Invalid - space in the wrong place",
+			phoneNumber:    "351 21 0942 000",
 			countryCode:    "",
 			expectedStatus: http.StatusBadRequest,
 			expectError:    true,
 			errorKey:       "phoneNumber",
-			errorValue:     "invalid space placement",
+			errorValue:     "invalid space placement within local phone number",
+		},
+		{
+			name:           "Invalid - space inside area code",
+			phoneNumber:    "+12 125690123",
+			countryCode:    "",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+			errorKey:       "phoneNumber",
+			errorValue:     "invalid space placement within area code",
+		},
+		{
+			name:           "Invalid - too short for the country",
+			phoneNumber:    "+1212",
+			countryCode:    "",
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+			errorKey:       "phoneNumber",
+			errorValue:     "too short",
 		},
 		{
 			name:           "Invalid - missing country code",
@@ -170,6 +200,764 @@ func TestPhoneNumberHandlerIntegration(t *testing.T) {
 				if result.LocalPhoneNumber != tt.expectedResult.LocalPhoneNumber {
 					t.Errorf("Expected localPhoneNumber %s, got %s", tt.expectedResult.LocalPhoneNumber, result.LocalPhoneNumber)
 				}
+				if tt.expectedResult.NumberType != "" && result.NumberType != tt.expectedResult.NumberType {
+					t.Errorf("Expected numberType %s, got %s", tt.expectedResult.NumberType, result.NumberType)
+				}
+				if tt.expectedResult.Formats != (PhoneNumberFormats{}) && result.Formats != tt.expectedResult.Formats {
+					t.Errorf("Expected formats %+v, got %+v", tt.expectedResult.Formats, result.Formats)
+				}
+			}
+		})
+	}
+}
+
+func TestPhoneNumberHandlerQueryOptions(t *testing.T) {
+	router := setupTestRouter()
+
+	tests := []struct {
+		name                string
+		query               url.Values
+		expectedStatus      int
+		errorKey            string
+		errorValue          string
+		expectedPhoneNumber string
+		expectedWarnings    int
+	}{
+		{
+			name:                "Lenient mode",
+			query:               url.Values{"phoneNumber": {"(212) 569-0123"}, "countryCode": {"US"}, "mode": {"lenient"}},
+			expectedStatus:      http.StatusOK,
+			expectedPhoneNumber: "+12125690123",
+		},
+		{
+			name:                "Strict mode",
+			query:               url.Values{"phoneNumber": {"(212) 569-0123"}, "countryCode": {"US"}, "mode": {"strict"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "phoneNumber",
+			errorValue:          "invalid format",
+			expectedPhoneNumber: "(212) 569-0123",
+		},
+		{
+			name:                "Strict is the default",
+			query:               url.Values{"phoneNumber": {"212.569.0123"}, "countryCode": {"US"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "phoneNumber",
+			errorValue:          "invalid format",
+			expectedPhoneNumber: "212.569.0123",
+		},
+		{
+			name:                "tel URI",
+			query:               url.Values{"phoneNumber": {"tel:5690123;phone-context=+1212"}},
+			expectedStatus:      http.StatusOK,
+			expectedPhoneNumber: "+12125690123",
+		},
+		{
+			name:                "tel URI without phone-context",
+			query:               url.Values{"phoneNumber": {"tel:5690123"}, "countryCode": {"US"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "phoneNumber",
+			errorValue:          "missing phone-context",
+			expectedPhoneNumber: "tel:5690123",
+		},
+		{
+			name:                "Vanity number",
+			query:               url.Values{"phoneNumber": {"1-800-FLOWERS"}, "allowVanity": {"true"}},
+			expectedStatus:      http.StatusOK,
+			expectedPhoneNumber: "+18003569377",
+		},
+		{
+			name:                "Unknown vanity setting",
+			query:               url.Values{"phoneNumber": {"1-800-FLOWERS"}, "allowVanity": {"maybe"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "allowVanity",
+			errorValue:          "invalid value",
+			expectedPhoneNumber: "1-800-FLOWERS",
+		},
+		{
+			name:                "Country code conflict rejected",
+			query:               url.Values{"phoneNumber": {"+34915872200"}, "countryCode": {"MX"}, "countryCodeConflict": {"reject"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "countryCode",
+			errorValue:          "conflicts with phone number country ES",
+			expectedPhoneNumber: "+34915872200",
+		},
+		{
+			name:                "Country code conflict warns by default",
+			query:               url.Values{"phoneNumber": {"+34915872200"}, "countryCode": {"MX"}},
+			expectedStatus:      http.StatusOK,
+			expectedPhoneNumber: "+34915872200",
+			expectedWarnings:    1,
+		},
+		{
+			name:                "Unknown conflict handling",
+			query:               url.Values{"phoneNumber": {"+34915872200"}, "countryCodeConflict": {"ignore"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "countryCodeConflict",
+			errorValue:          "invalid value",
+			expectedPhoneNumber: "+34915872200",
+		},
+		{
+			name:                "Unknown mode",
+			query:               url.Values{"phoneNumber": {"2125690123"}, "countryCode": {"US"}, "mode": {"relaxed"}},
+			expectedStatus:      http.StatusBadRequest,
+			errorKey:            "mode",
+			errorValue:          "invalid value",
+			expectedPhoneNumber: "2125690123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/phone-numbers?"+tt.query.Encode(), nil)
+			if err != nil {
+				t.Fatalf("Could not create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.errorKey != "" {
+				var errorResp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Fatalf("Could not parse error response: %v", err)
+				}
+				if errorResp.Error[tt.errorKey] != tt.errorValue {
+					t.Errorf("Expected error %s: %s, got %s: %s",
+						tt.errorKey, tt.errorValue, tt.errorKey, errorResp.Error[tt.errorKey])
+				}
+				if errorResp.PhoneNumber != tt.expectedPhoneNumber {
+					t.Errorf("Expected error phoneNumber %s, got %s", tt.expectedPhoneNumber, errorResp.PhoneNumber)
+				}
+			} else {
+				var result PhoneNumberResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+					t.Fatalf("Could not parse success response: %v", err)
+				}
+				if result.PhoneNumber != tt.expectedPhoneNumber {
+					t.Errorf("Expected phoneNumber %s, got %s", tt.expectedPhoneNumber, result.PhoneNumber)
+				}
+				if len(result.Warnings) != tt.expectedWarnings {
+					t.Errorf("Expected %d warnings, got %v", tt.expectedWarnings, result.Warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractHandlerIntegration(t *testing.T) {
+	router := setupTestRouter()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		errorKey       string
+		errorValue     string
+		expectedCount  int
+	}{
+		{
+			name:           "Numbers in text",
+			body:           `{"text": "Call (212) 569-0123 or +44 20 7946 0018", "countryCode": "US"}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "No numbers in text",
+			body:           `{"text": "Nothing here", "countryCode": "US"}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  0,
+		},
+		{
+			name:           "Missing text",
+			body:           `{"countryCode": "US"}`,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "text",
+			errorValue:     "required value is missing",
+		},
+		{
+			name:           "Invalid country code",
+			body:           `{"text": "Call 569 0123", "countryCode": "USA"}`,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "countryCode",
+			errorValue:     "invalid format",
+		},
+		{
+			name:           "Unsupported country code",
+			body:           `{"text": "Call 569 0123", "countryCode": "ZZ"}`,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "countryCode",
+			errorValue:     "unsupported country",
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"text": `,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "body",
+			errorValue:     "invalid JSON",
+		},
+		{
+			name:           "Body too large",
+			body:           `{"text": "` + strings.Repeat("a", maxExtractBodyBytes) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			errorKey:       "body",
+			errorValue:     "too large, the maximum is 65536 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/v1/phone-numbers/extract", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Could not create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.errorKey != "" {
+				var errorResp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Fatalf("Could not parse error response: %v", err)
+				}
+				if errorResp.Error[tt.errorKey] != tt.errorValue {
+					t.Errorf("Expected error %s: %s, got %s: %s",
+						tt.errorKey, tt.errorValue, tt.errorKey, errorResp.Error[tt.errorKey])
+				}
+			} else {
+				var result ExtractResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+					t.Fatalf("Could not parse success response: %v", err)
+				}
+				if len(result.PhoneNumbers) != tt.expectedCount {
+					t.Errorf("Expected %d phone numbers, got %d", tt.expectedCount, len(result.PhoneNumbers))
+				}
+			}
+		})
+	}
+}
+
+func TestFormatAsYouTypeHandlerIntegration(t *testing.T) {
+	router := setupTestRouter()
+
+	tests := []struct {
+		name              string
+		query             url.Values
+		expectedStatus    int
+		errorKey          string
+		errorValue        string
+		expectedFormatted string
+		expectedCountry   string
+	}{
+		{
+			name:              "National digits",
+			query:             url.Values{"digits": {"21256"}, "countryCode": {"US"}},
+			expectedStatus:    http.StatusOK,
+			expectedFormatted: "(212) 56",
+			expectedCountry:   "US",
+		},
+		{
+			name:              "International digits",
+			query:             url.Values{"digits": {"+442079"}},
+			expectedStatus:    http.StatusOK,
+			expectedFormatted: "+44 20 79",
+			expectedCountry:   "GB",
+		},
+		{
+			name:              "Country not known yet",
+			query:             url.Values{"digits": {"2125"}},
+			expectedStatus:    http.StatusOK,
+			expectedFormatted: "2125",
+			expectedCountry:   "",
+		},
+		{
+			name:           "Missing digits",
+			query:          url.Values{"countryCode": {"US"}},
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "digits",
+			errorValue:     "required parameter is missing",
+		},
+		{
+			name:           "Invalid country code",
+			query:          url.Values{"digits": {"212"}, "countryCode": {"USA"}},
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "countryCode",
+			errorValue:     "invalid format",
+		},
+		{
+			name:           "Unsupported country code",
+			query:          url.Values{"digits": {"212"}, "countryCode": {"ZZ"}},
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "countryCode",
+			errorValue:     "unsupported country",
+		},
+		{
+			name:              "Longest number typed with an international call prefix",
+			query:             url.Values{"digits": {"011 44 " + strings.Repeat("1", maxAsYouTypeDigits-5)}, "countryCode": {"US"}},
+			expectedStatus:    http.StatusOK,
+			expectedFormatted: "011 44 " + strings.Repeat("1", maxAsYouTypeDigits-5),
+			expectedCountry:   "GB",
+		},
+		{
+			name:           "Too many digits",
+			query:          url.Values{"digits": {strings.Repeat("2", maxAsYouTypeDigits+1)}, "countryCode": {"US"}},
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "digits",
+			errorValue:     "too long",
+		},
+		{
+			name:           "Too many separators",
+			query:          url.Values{"digits": {"212" + strings.Repeat(" ", maxAsYouTypeLength)}, "countryCode": {"US"}},
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "digits",
+			errorValue:     "too long",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/phone-numbers/format-as-you-type?"+tt.query.Encode(), nil)
+			if err != nil {
+				t.Fatalf("Could not create request: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.errorKey != "" {
+				var errorResp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Fatalf("Could not parse error response: %v", err)
+				}
+				if errorResp.Error[tt.errorKey] != tt.errorValue {
+					t.Errorf("Expected error %s: %s, got %s: %s",
+						tt.errorKey, tt.errorValue, tt.errorKey, errorResp.Error[tt.errorKey])
+				}
+			} else {
+				var result AsYouTypeResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+					t.Fatalf("Could not parse success response: %v", err)
+				}
+				if result.Formatted != tt.expectedFormatted || result.CountryCode != tt.expectedCountry {
+					t.Errorf("Expected %s (%s), got %s (%s)", tt.expectedFormatted, tt.expectedCountry, result.Formatted, result.CountryCode)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchHandlerIntegration(t *testing.T) {
+	router := setupTestRouter()
+
+	tooMany := "[" + strings.Repeat(`{"phoneNumber": "+12125690123"},`, maxBatchItems) + `{"phoneNumber": "+12125690123"}]`
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		errorKey       string
+		errorValue     string
+		expectedCount  int
+	}{
+		{
+			name:           "Batch of numbers",
+			path:           "/v1/phone-numbers:batch",
+			body:           `[{"phoneNumber": "+12125690123"}, {"phoneNumber": "631 311 8150"}, {"phoneNumber": "212 569 0123", "countryCode": "US"}]`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+		},
+		{
+			name:           "Query options apply to every item",
+			path:           "/v1/phone-numbers:batch?mode=lenient",
+			body:           `[{"phoneNumber": "(212) 569-0123", "countryCode": "US"}]`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:           "Invalid query option",
+			path:           "/v1/phone-numbers:batch?mode=loose",
+			body:           `[{"phoneNumber": "+12125690123"}]`,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "mode",
+			errorValue:     "invalid value",
+		},
+		{
+			name:           "Empty batch",
+			path:           "/v1/phone-numbers:batch",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "body",
+			errorValue:     "required value is missing",
+		},
+		{
+			name:           "Too many items",
+			path:           "/v1/phone-numbers:batch",
+			body:           tooMany,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "body",
+			errorValue:     "too many items, the maximum is 1000",
+		},
+		{
+			name:           "Body too large",
+			path:           "/v1/phone-numbers:batch",
+			body:           `[{"phoneNumber": "` + strings.Repeat(" ", maxBatchItems*maxBatchItemBytes) + `"}]`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			errorKey:       "body",
+			errorValue:     "too large, the maximum is 1024000 bytes",
+		},
+		{
+			name:           "Not an array",
+			path:           "/v1/phone-numbers:batch",
+			body:           `{"phoneNumber": "+12125690123"}`,
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "body",
+			errorValue:     "invalid JSON",
+		},
+		{
+			name:           "Unknown method",
+			path:           "/v1/phone-numbers:delete",
+			body:           `[]`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Method without a colon",
+			path:           "/v1/phone-numbersbatch",
+			body:           `[{"phoneNumber": "+12125690123"}]`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Could not create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.errorKey != "" {
+				var errorResp ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+					t.Fatalf("Could not parse error response: %v", err)
+				}
+				if errorResp.Error[tt.errorKey] != tt.errorValue {
+					t.Errorf("Expected error %s: %s, got %s: %s",
+						tt.errorKey, tt.errorValue, tt.errorKey, errorResp.Error[tt.errorKey])
+				}
+			} else if tt.expectedStatus == http.StatusOK {
+				var results []map[string]any
+				if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+					t.Fatalf("Could not parse success response: %v", err)
+				}
+				if len(results) != tt.expectedCount {
+					t.Errorf("Expected %d results, got %d", tt.expectedCount, len(results))
+				}
+				// Only 631 311 8150 fails, it doesn't have a country code
+				for i, result := range results {
+					if _, failed := result["error"]; failed && i != 1 {
+						t.Errorf("Item %d: expected a phone number, got %v", i, result)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStreamHandlerIntegration(t *testing.T) {
+	router := setupTestRouter()
+
+	body := `{"id": 1, "phoneNumber": "(212) 569-0123", "countryCode": "US"}` + "\n" + `{"id": 2, "phoneNumber": "+34915872200"}` + "\n"
+	req, err := http.NewRequest("POST", "/v1/phone-numbers:stream?mode=lenient", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Could not create request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %s", contentType)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	expected := []string{"+12125690123", "+34915872200"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		var result struct {
+			Result PhoneNumberResponse `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("Could not parse line %d: %v", i+1, err)
+		}
+		if result.Result.PhoneNumber != expected[i] {
+			t.Errorf("Line %d: expected %s, got %s", i+1, expected[i], result.Result.PhoneNumber)
+		}
+	}
+
+	// Bad options fail before anything is streamed
+	req, _ = http.NewRequest("POST", "/v1/phone-numbers:stream?mode=loose", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid mode, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// Builds a multipart upload of the file along with any other form fields
+func newUploadRequest(t *testing.T, target, filename, content string, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatalf("Could not create upload: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	req, err := http.NewRequest("POST", target, &body)
+	if err != nil {
+		t.Fatalf("Could not create request: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestJobsHandlerIntegration(t *testing.T) {
+	store, err := openJobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Could not open job store: %v", err)
+	}
+
+	router := setupTestRouter()
+	router.POST("/v1/jobs", store.submitHandler)
+	router.GET("/v1/jobs/:id", store.statusHandler)
+	router.GET("/v1/jobs/:id/results", store.resultsHandler)
+
+	req := newUploadRequest(t, "/v1/jobs?mode=lenient", "numbers.csv", "phoneNumber,countryCode\n(212) 569-0123,US\n+34915872200,\n", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var submitted Job
+	if err := json.Unmarshal(w.Body.Bytes(), &submitted); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if submitted.Format != JobFormatCSV || submitted.Options.Mode != ModeLenient {
+		t.Errorf("Expected a lenient csv job, got %s %s", submitted.Options.Mode, submitted.Format)
+	}
+
+	// Results aren't ready until the job has run
+	req, _ = http.NewRequest("GET", "/v1/jobs/"+submitted.ID+"/results", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d before the job has run, got %d", http.StatusConflict, w.Code)
+	}
+
+	store.start(1)
+	waitForJob(t, store, submitted.ID)
+
+	req, _ = http.NewRequest("GET", "/v1/jobs/"+submitted.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var job Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if w.Code != http.StatusOK || job.Status != JobDone || job.Total != 2 || job.Succeeded != 2 {
+		t.Errorf("Expected a done job with 2 succeeded, got status %d: %s", w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/v1/jobs/"+submitted.ID+"/results", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %s", contentType)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "+12125690123") || !strings.Contains(lines[1], "+34915872200") {
+		t.Errorf("Unexpected results: %s", w.Body.String())
+	}
+
+	errorTests := []struct {
+		name           string
+		req            *http.Request
+		expectedStatus int
+		errorKey       string
+		errorValue     string
+	}{
+		{
+			name:           "Missing file",
+			req:            newUploadRequest(t, "/v1/jobs", "", "", map[string]string{"format": "csv"}),
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "file",
+			errorValue:     "required value is missing",
+		},
+		{
+			name:           "Unknown format",
+			req:            newUploadRequest(t, "/v1/jobs", "numbers.txt", "+12125690123", nil),
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "format",
+			errorValue:     "invalid value",
+		},
+		{
+			name:           "Invalid mode",
+			req:            newUploadRequest(t, "/v1/jobs?mode=loose", "numbers.csv", "phoneNumber\n", nil),
+			expectedStatus: http.StatusBadRequest,
+			errorKey:       "mode",
+			errorValue:     "invalid value",
+		},
+		{
+			name:           "Unknown job",
+			req:            httptest.NewRequest("GET", "/v1/jobs/0123456789abcdef", nil),
+			expectedStatus: http.StatusNotFound,
+			errorKey:       "id",
+			errorValue:     "job not found",
+		},
+		{
+			name:           "Results of an unknown job",
+			req:            httptest.NewRequest("GET", "/v1/jobs/0123456789abcdef/results", nil),
+			expectedStatus: http.StatusNotFound,
+			errorKey:       "id",
+			errorValue:     "job not found",
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var errorResp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+				t.Fatalf("Could not parse response: %v", err)
+			}
+			if errorResp.Error[tt.errorKey] != tt.errorValue {
+				t.Errorf("Expected error %s: %s, got %v", tt.errorKey, tt.errorValue, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestNormalizeCSVHandlerIntegration(t *testing.T) {
+	router := setupTestRouter()
+
+	upload := "name,phone,country\nAlice,(212) 569-0123,US\nBob,631 311 8150,\n"
+	req := newUploadRequest(t, "/v1/phone-numbers:normalizeCsv?mode=lenient", "contacts.csv", upload, map[string]string{
+		"numberColumn":  "phone",
+		"countryColumn": "country",
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+		t.Errorf("Expected text/csv, got %s", contentType)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment; filename=contacts-normalized.csv" {
+		t.Errorf("Expected contacts-normalized.csv attachment, got %s", disposition)
+	}
+
+	expected := "name,phone,country,normalized_e164,normalized_countryCode,normalized_areaCode,normalized_localPhoneNumber,normalized_error\n" +
+		"Alice,(212) 569-0123,US,+12125690123,US,212,5690123,\n" +
+		"Bob,631 311 8150,,,,,,countryCode: required value is missing\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, w.Body.String())
+	}
+
+	errorTests := []struct {
+		name       string
+		req        *http.Request
+		errorKey   string
+		errorValue string
+	}{
+		{
+			name:       "Missing file",
+			req:        newUploadRequest(t, "/v1/phone-numbers:normalizeCsv", "", "", map[string]string{"numberColumn": "phone"}),
+			errorKey:   "file",
+			errorValue: "required value is missing",
+		},
+		{
+			name:       "Missing number column",
+			req:        newUploadRequest(t, "/v1/phone-numbers:normalizeCsv", "contacts.csv", upload, nil),
+			errorKey:   "numberColumn",
+			errorValue: "required value is missing",
+		},
+		{
+			name:       "Unknown number column",
+			req:        newUploadRequest(t, "/v1/phone-numbers:normalizeCsv", "contacts.csv", upload, map[string]string{"numberColumn": "mobile"}),
+			errorKey:   "numberColumn",
+			errorValue: "column not found",
+		},
+		{
+			name:       "Invalid mode",
+			req:        newUploadRequest(t, "/v1/phone-numbers:normalizeCsv?mode=loose", "contacts.csv", upload, map[string]string{"numberColumn": "phone"}),
+			errorKey:   "mode",
+			errorValue: "invalid value",
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+			var errorResp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+				t.Fatalf("Could not parse response: %v", err)
+			}
+			if errorResp.Error[tt.errorKey] != tt.errorValue {
+				t.Errorf("Expected error %s: %s, got %v", tt.errorKey, tt.errorValue, errorResp.Error)
 			}
 		})
 	}
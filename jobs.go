@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobStatus is how far along a bulk job is
+type JobStatus string
+
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// Upload formats a job can be submitted in
+const (
+	JobFormatCSV    = "csv"    // Header row with phoneNumber and optionally countryCode and id columns
+	JobFormatNDJSON = "ndjson" // One StreamItem per line, same as the :stream endpoint
+)
+
+// Job is a bulk upload being parsed in the background
+type Job struct {
+	ID        string       `json:"id"`
+	Status    JobStatus    `json:"status"`
+	Format    string       `json:"format"`
+	Options   ParseOptions `json:"options"`
+	Total     int          `json:"total"`     // Rows in the upload, counted when the job starts
+	Processed int          `json:"processed"` // Rows parsed so far
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Error     string       `json:"error,omitempty"` // Why the job itself failed, i.e. a broken CSV
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// How many jobs run at the same time
+const jobWorkers = 2
+
+// Most jobs that can be waiting to run
+const maxQueuedJobs = 1000
+
+// How many rows are parsed between saving the job's progress to disk
+const jobProgressInterval = 1000
+
+var errJobQueueFull = errors.New("too many jobs queued")
+
+// JobStore keeps track of the jobs and runs them. Each job has its own directory under dir so nothing is lost on a
+// restart: job.json is the Job, input.csv or input.ndjson is the upload and results.ndjson has one StreamResult per row.
+// Jobs that were queued or running when we stopped are started again from the beginning
+type JobStore struct {
+	dir   string
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	queue chan string
+}
+
+// Opens the jobs directory, creating it if needed, and loads the jobs already in it
+func openJobStore(dir string) (*JobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	store := &JobStore{
+		dir:  dir,
+		jobs: make(map[string]*Job),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var unfinished []*Job
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name(), "job.json"))
+		if err != nil {
+			log.Printf("Skipping job %s: %v", entry.Name(), err)
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil || job.ID != entry.Name() {
+			log.Printf("Skipping job %s: invalid job.json", entry.Name())
+			continue
+		}
+
+		store.jobs[job.ID] = &job
+		if job.Status == JobQueued || job.Status == JobRunning {
+			unfinished = append(unfinished, &job)
+		}
+	}
+
+	// Oldest first so they run in the order they were submitted
+	sort.Slice(unfinished, func(i, j int) bool {
+		return unfinished[i].CreatedAt.Before(unfinished[j].CreatedAt)
+	})
+
+	// The queue always has room for the jobs we're picking up again, the limit is only for new ones. Running jobs
+	// were already out of the queue so there can be more of these than maxQueuedJobs
+	store.queue = make(chan string, max(maxQueuedJobs, len(unfinished)))
+	for _, job := range unfinished {
+		job.Status = JobQueued
+		job.Processed, job.Succeeded, job.Failed = 0, 0, 0
+		if err := store.save(job); err != nil {
+			return nil, err
+		}
+		if err := store.enqueue(job.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// Starts the workers that run the jobs
+func (store *JobStore) start(workers int) {
+	for range workers {
+		go func() {
+			for id := range store.queue {
+				store.run(id)
+			}
+		}()
+	}
+}
+
+// Saves the upload as a new job and queues it
+func (store *JobStore) submit(upload io.Reader, format string, options ParseOptions) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	jobDir := filepath.Join(store.dir, id)
+	if err := os.Mkdir(jobDir, 0o755); err != nil {
+		return Job{}, err
+	}
+
+	input, err := os.Create(filepath.Join(jobDir, "input."+format))
+	if err != nil {
+		os.RemoveAll(jobDir)
+		return Job{}, err
+	}
+	_, err = io.Copy(input, upload)
+	if closeErr := input.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(jobDir)
+		return Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        id,
+		Status:    JobQueued,
+		Format:    format,
+		Options:   options,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.saveLocked(job); err != nil {
+		os.RemoveAll(jobDir)
+		return Job{}, err
+	}
+	if err := store.enqueue(id); err != nil {
+		os.RemoveAll(jobDir)
+		return Job{}, err
+	}
+	store.jobs[id] = job
+
+	return *job, nil
+}
+
+// A copy of the job as it is right now
+func (store *JobStore) get(id string) (Job, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	job, exists := store.jobs[id]
+	if !exists {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (store *JobStore) resultsPath(id string) string {
+	return filepath.Join(store.dir, id, "results.ndjson")
+}
+
+func (store *JobStore) enqueue(id string) error {
+	select {
+	case store.queue <- id:
+		return nil
+	default:
+		return errJobQueueFull
+	}
+}
+
+// Parses every row of the job's upload into its results file, saving progress as it goes
+func (store *JobStore) run(id string) {
+	store.mu.Lock()
+	job := store.jobs[id]
+	job.Status = JobRunning
+	inputPath := filepath.Join(store.dir, id, "input."+job.Format)
+	format, options := job.Format, job.Options
+	if err := store.saveLocked(job); err != nil {
+		log.Printf("Could not save job %s: %v", id, err)
+	}
+	store.mu.Unlock()
+
+	err := store.process(job, inputPath, format, options)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if err != nil {
+		job.Status, job.Error = JobFailed, err.Error()
+	} else {
+		job.Status = JobDone
+	}
+	if err := store.saveLocked(job); err != nil {
+		log.Printf("Could not save job %s: %v", id, err)
+	}
+}
+
+func (store *JobStore) process(job *Job, inputPath, format string, options ParseOptions) error {
+	total, err := countJobRows(inputPath, format)
+	if err != nil {
+		return err
+	}
+	store.mu.Lock()
+	job.Total = total
+	store.mu.Unlock()
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	output, err := os.Create(store.resultsPath(job.ID))
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	writer := bufio.NewWriter(output)
+	encoder := json.NewEncoder(writer)
+
+	err = readJobRows(input, format, options, func(result StreamResult) error {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		job.Processed++
+		if _, failed := result.Result.(*ErrorResponse); failed {
+			job.Failed++
+		} else {
+			job.Succeeded++
+		}
+
+		if job.Processed%jobProgressInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			return store.saveLocked(job)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Saves the job to its job.json, written to a temporary file first so a crash never leaves half a file
+func (store *JobStore) saveLocked(job *Job) error {
+	job.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(store.dir, job.ID, "job.json")
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (store *JobStore) save(job *Job) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.saveLocked(job)
+}
+
+// Reads each row of a job's upload and hands its result to emit, stopping at the first error
+func readJobRows(reader io.Reader, format string, options ParseOptions, emit func(StreamResult) error) error {
+	if format == JobFormatNDJSON {
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 4096), maxStreamLineLength)
+
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			if err := emit(parseStreamLine(lineNumber, scanner.Bytes(), options)); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return fmt.Errorf("missing header row")
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := csvColumns(header)
+	if _, exists := columns["phoneNumber"]; !exists {
+		return fmt.Errorf("missing phoneNumber column")
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		item := BatchItem{
+			PhoneNumber: csvField(record, columns, "phoneNumber"),
+			CountryCode: csvField(record, columns, "countryCode"),
+		}
+
+		result := StreamResult{Line: line, Result: parseBatchItem(item, options)}
+		if id := csvField(record, columns, "id"); id != "" {
+			result.ID, _ = json.Marshal(id)
+		}
+
+		if err := emit(result); err != nil {
+			return err
+		}
+	}
+}
+
+// Counts the rows in an upload so we can report progress
+func countJobRows(path, format string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if format == JobFormatNDJSON {
+		count := 0
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 4096), maxStreamLineLength)
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > 0 {
+				count++
+			}
+		}
+		return count, scanner.Err()
+	}
+
+	csvReader := csv.NewReader(file)
+	csvReader.FieldsPerRecord = -1
+	count := -1 // The header isn't a row
+	for {
+		_, err := csvReader.Read()
+		if err == io.EOF {
+			return max(count, 0), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+}
+
+// Maps each column name in a CSV header to its index. Spreadsheets often save a byte order mark at the start of the
+// file, which would otherwise end up in the first column's name
+func csvColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.TrimSpace(name)] = i
+	}
+	return columns
+}
+
+// The value of a column in a CSV record, empty if there's no such column or the record is short
+func csvField(record []string, columns map[string]int, name string) string {
+	i, exists := columns[name]
+	if !exists || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// Works out the upload format from its file name i.e. numbers.csv
+func jobFormatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return JobFormatCSV
+	case ".ndjson", ".jsonl":
+		return JobFormatNDJSON
+	}
+	return ""
+}
+
+func newJobID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
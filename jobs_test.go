@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Waits for the job to finish, failing the test if it takes too long
+func waitForJob(t *testing.T, store *JobStore, id string) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, exists := store.get(id)
+		if !exists {
+			t.Fatalf("Job %s not found", id)
+		}
+		if job.Status == JobDone || job.Status == JobFailed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Job %s didn't finish", id)
+	return Job{}
+}
+
+type jobResultLine struct {
+	Line   int             `json:"line"`
+	ID     json.RawMessage `json:"id"`
+	Result struct {
+		PhoneNumber string            `json:"phoneNumber"`
+		Error       map[string]string `json:"error"`
+	} `json:"result"`
+}
+
+func readJobResults(t *testing.T, store *JobStore, id string) []jobResultLine {
+	t.Helper()
+
+	data, err := os.ReadFile(store.resultsPath(id))
+	if err != nil {
+		t.Fatalf("Could not read results: %v", err)
+	}
+
+	var results []jobResultLine
+	for i, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var result jobResultLine
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("Line %d isn't JSON: %v", i+1, err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestJobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		expected []struct {
+			line        int
+			id          string
+			phoneNumber string
+			errorKey    string
+		}
+	}{
+		{
+			name:   "NDJSON",
+			format: JobFormatNDJSON,
+			input: strings.Join([]string{
+				`{"id": "a", "phoneNumber": "+12125690123"}`,
+				``,
+				`{"phoneNumber": "631 311 8150", "countryCode": "MX"}`,
+				`{"phoneNumber": "631 311 8150"}`,
+				`not json`,
+			}, "\n"),
+			expected: []struct {
+				line        int
+				id          string
+				phoneNumber string
+				errorKey    string
+			}{
+				{1, `"a"`, "+12125690123", ""},
+				{3, ``, "+526313118150", ""},
+				{4, ``, "631 311 8150", "countryCode"},
+				{5, ``, "", "body"},
+			},
+		},
+		{
+			name:   "CSV",
+			format: JobFormatCSV,
+			input:  "id,phoneNumber,countryCode\nr1,+12125690123,\nr2,631 311 8150,MX\n\"r3\",\"020 7946\n0018\",GB\nr4,,US\n",
+			expected: []struct {
+				line        int
+				id          string
+				phoneNumber string
+				errorKey    string
+			}{
+				{2, `"r1"`, "+12125690123", ""},
+				{3, `"r2"`, "+526313118150", ""},
+				{4, `"r3"`, "020 7946\n0018", "phoneNumber"},
+				{6, `"r4"`, "", "phoneNumber"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := openJobStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Could not open job store: %v", err)
+			}
+			store.start(1)
+
+			submitted, err := store.submit(strings.NewReader(tt.input), tt.format, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+			if err != nil {
+				t.Fatalf("Could not submit job: %v", err)
+			}
+			if submitted.Status != JobQueued {
+				t.Errorf("Expected a new job to be queued, got %s", submitted.Status)
+			}
+
+			job := waitForJob(t, store, submitted.ID)
+			if job.Status != JobDone {
+				t.Fatalf("Expected job to be done, got %s: %s", job.Status, job.Error)
+			}
+
+			failed := 0
+			for _, want := range tt.expected {
+				if want.errorKey != "" {
+					failed++
+				}
+			}
+			if job.Total != len(tt.expected) || job.Processed != len(tt.expected) || job.Failed != failed || job.Succeeded != len(tt.expected)-failed {
+				t.Errorf("Expected %d rows with %d failed, got total %d processed %d succeeded %d failed %d",
+					len(tt.expected), failed, job.Total, job.Processed, job.Succeeded, job.Failed)
+			}
+
+			results := readJobResults(t, store, job.ID)
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Expected %d results, got %d", len(tt.expected), len(results))
+			}
+			for i, want := range tt.expected {
+				result := results[i]
+				if result.Line != want.line || string(result.ID) != want.id || result.Result.PhoneNumber != want.phoneNumber {
+					t.Errorf("Expected line %d id %s phoneNumber %q, got line %d id %s phoneNumber %q",
+						want.line, want.id, want.phoneNumber, result.Line, result.ID, result.Result.PhoneNumber)
+				}
+				if _, exists := result.Result.Error[want.errorKey]; want.errorKey != "" && !exists {
+					t.Errorf("Line %d: expected %s error, got %v", want.line, want.errorKey, result.Result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestJobsInvalidCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"Empty", "", "missing header row"},
+		{"No phoneNumber column", "number,country\n+12125690123,US\n", "missing phoneNumber column"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := openJobStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Could not open job store: %v", err)
+			}
+			store.start(1)
+
+			submitted, err := store.submit(strings.NewReader(tt.input), JobFormatCSV, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+			if err != nil {
+				t.Fatalf("Could not submit job: %v", err)
+			}
+
+			job := waitForJob(t, store, submitted.ID)
+			if job.Status != JobFailed || job.Error != tt.err {
+				t.Errorf("Expected job to fail with %q, got %s %q", tt.err, job.Status, job.Error)
+			}
+		})
+	}
+}
+
+func TestJobsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	// Submitted but never started, as if we stopped before getting to it
+	store, err := openJobStore(dir)
+	if err != nil {
+		t.Fatalf("Could not open job store: %v", err)
+	}
+	submitted, err := store.submit(strings.NewReader(`{"phoneNumber": "+12125690123"}`), JobFormatNDJSON, ParseOptions{Mode: ModeLenient, CountryCodeConflict: ConflictWarn})
+	if err != nil {
+		t.Fatalf("Could not submit job: %v", err)
+	}
+
+	restarted, err := openJobStore(dir)
+	if err != nil {
+		t.Fatalf("Could not reopen job store: %v", err)
+	}
+
+	job, exists := restarted.get(submitted.ID)
+	if !exists {
+		t.Fatalf("Expected job %s to be loaded", submitted.ID)
+	}
+	if job.Status != JobQueued || job.Options.Mode != ModeLenient {
+		t.Errorf("Expected a queued lenient job, got %s %s", job.Status, job.Options.Mode)
+	}
+
+	restarted.start(1)
+	job = waitForJob(t, restarted, submitted.ID)
+	if job.Status != JobDone || job.Succeeded != 1 {
+		t.Errorf("Expected job to be done with 1 succeeded, got %s with %d", job.Status, job.Succeeded)
+	}
+
+	// Finished jobs are loaded as they were and not run again
+	reopened, err := openJobStore(dir)
+	if err != nil {
+		t.Fatalf("Could not reopen job store: %v", err)
+	}
+	if job, _ := reopened.get(submitted.ID); job.Status != JobDone || len(reopened.queue) != 0 {
+		t.Errorf("Expected the finished job to stay done and not be queued, got %s with %d queued", job.Status, len(reopened.queue))
+	}
+}
+
+func TestJobsSurviveRestartWithAFullQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	// A full queue plus the jobs that were running when we stopped
+	writer := &JobStore{dir: dir}
+	for i := range maxQueuedJobs + jobWorkers {
+		status := JobQueued
+		if i < jobWorkers {
+			status = JobRunning
+		}
+
+		job := &Job{ID: fmt.Sprintf("%032x", i), Status: status, Format: JobFormatNDJSON, CreatedAt: time.Now().UTC()}
+		if err := os.Mkdir(filepath.Join(dir, job.ID), 0o755); err != nil {
+			t.Fatalf("Could not create job directory: %v", err)
+		}
+		if err := writer.saveLocked(job); err != nil {
+			t.Fatalf("Could not save job: %v", err)
+		}
+	}
+
+	store, err := openJobStore(dir)
+	if err != nil {
+		t.Fatalf("Expected the jobs to be picked up again, got %v", err)
+	}
+	if len(store.queue) != maxQueuedJobs+jobWorkers {
+		t.Errorf("Expected %d jobs queued, got %d", maxQueuedJobs+jobWorkers, len(store.queue))
+	}
+
+	// New jobs still have to wait for room
+	if _, err := store.submit(strings.NewReader(""), JobFormatNDJSON, ParseOptions{}); !errors.Is(err, errJobQueueFull) {
+		t.Errorf("Expected %v submitting to a full queue, got %v", errJobQueueFull, err)
+	}
+}
+
+func TestJobFormatFromFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{"numbers.csv", JobFormatCSV},
+		{"NUMBERS.CSV", JobFormatCSV},
+		{"numbers.ndjson", JobFormatNDJSON},
+		{"numbers.jsonl", JobFormatNDJSON},
+		{"numbers.txt", ""},
+		{"numbers", ""},
+	}
+
+	for _, tt := range tests {
+		if format := jobFormatFromFilename(tt.filename); format != tt.expected {
+			t.Errorf("jobFormatFromFilename(%q) = %q, expected %q", tt.filename, format, tt.expected)
+		}
+	}
+}
@@ -1,18 +1,40 @@
 package main
 
 import (
+	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PhoneNumberResponse struct {
-	PhoneNumber      string `json:"phoneNumber"`
-	CountryCode      string `json:"countryCode"`
-	AreaCode         string `json:"areaCode"`
-	LocalPhoneNumber string `json:"localPhoneNumber"`
+	PhoneNumber      string             `json:"phoneNumber"`
+	CountryCode      string             `json:"countryCode"`
+	AreaCode         string             `json:"areaCode"`
+	LocalPhoneNumber string             `json:"localPhoneNumber"`
+	Extension        string             `json:"extension,omitempty"`
+	VanityNumber     string             `json:"vanityNumber,omitempty"`
+	SubAddress       string             `json:"subAddress,omitempty"`
+	NumberType       string             `json:"numberType"`
+	Formats          PhoneNumberFormats `json:"formats"`
+	Warnings         []Warning          `json:"warnings,omitempty"`
+	Candidates       []Candidate        `json:"candidates,omitempty"`
+}
+
+// Warning flags something odd about a request that we could still parse
+type Warning struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type ErrorResponse struct {
@@ -20,14 +42,61 @@ type ErrorResponse struct {
 	Error       map[string]string `json:"error"`
 }
 
+// Input modes for parsing
+const (
+	ModeStrict  = "strict"  // E.164 with spaces only between country code, area code and local number
+	ModeLenient = "lenient" // Also accepts punctuation like parentheses, dashes, dots and slashes
+)
+
+// What to do when the countryCode parameter doesn't match the country code in the number
+const (
+	ConflictWarn   = "warn"   // Parse the number and add a warning to the response
+	ConflictReject = "reject" // Fail with a countryCode error
+	ConflictIgnore = "ignore" // Parse the number without a warning, for when countryCode is only a default i.e. extracting
+)
+
+// ParseOptions changes how we treat the input when parsing
+type ParseOptions struct {
+	Mode                string `json:"mode"`
+	CountryCodeConflict string `json:"countryCodeConflict"`
+	AllowVanity         bool   `json:"allowVanity"` // Map keypad letters to digits i.e. 1-800-FLOWERS
+}
+
+// Reads the parsing options from the query string
+func parseOptionsFromQuery(c *gin.Context) (ParseOptions, *ErrorResponse) {
+	options := ParseOptions{
+		Mode:                c.DefaultQuery("mode", ModeStrict),
+		CountryCodeConflict: c.DefaultQuery("countryCodeConflict", ConflictWarn),
+	}
+
+	if options.Mode != ModeStrict && options.Mode != ModeLenient {
+		return options, &ErrorResponse{
+			Error: map[string]string{"mode": "invalid value"},
+		}
+	}
+
+	if options.CountryCodeConflict != ConflictWarn && options.CountryCodeConflict != ConflictReject {
+		return options, &ErrorResponse{
+			Error: map[string]string{"countryCodeConflict": "invalid value"},
+		}
+	}
+
+	allowVanity, err := strconv.ParseBool(c.DefaultQuery("allowVanity", "false"))
+	if err != nil {
+		return options, &ErrorResponse{
+			Error: map[string]string{"allowVanity": "invalid value"},
+		}
+	}
+	options.AllowVanity = allowVanity
+
+	return options, nil
+}
+
 func processNumberWithCountryCode(phoneNumber, dialCode, countryCode string) (*PhoneNumberResponse, *ErrorResponse) {
 	cleanNumber := cleanNumber(phoneNumber)
 
-	// Remove country code from number
-	remainingNumber := cleanNumber[len(dialCode):]
-
-	// Get area code length for this country
-	areaCodeLength, exists := AreaCodeMap[countryCode]
+	// Get the metadata for this country
+	region, exists := lookupRegion(countryCode)
 	if !exists {
 		return nil, &ErrorResponse{
 			PhoneNumber: phoneNumber,
@@ -35,25 +104,18 @@ func processNumberWithCountryCode(phoneNumber, dialCode, countryCode string) (*P
 		}
 	}
 
-	// Extract area code and local number
-	var areaCode, localNumber string
-	if areaCodeLength > 0 && len(remainingNumber) > areaCodeLength {
-		areaCode = remainingNumber[:areaCodeLength]
-		localNumber = remainingNumber[areaCodeLength:]
-	} else {
-		areaCode = ""
-		localNumber = remainingNumber
-	}
+	// Remove country code from number
+	remainingNumber := cleanNumber[len(dialCode):]
 
-	// Reconstruct full phone number with +
-	fullPhoneNumber := "+" + dialCode + remainingNumber
+	// Make sure what's left is a real number in this country
+	if reason := region.validateNationalNumber(remainingNumber); reason != "" {
+		return nil, &ErrorResponse{
+			PhoneNumber: phoneNumber,
+			Error:       map[string]string{"phoneNumber": reason},
+		}
+	}
 
-	return &PhoneNumberResponse{
-		PhoneNumber:      fullPhoneNumber,
-		CountryCode:      countryCode,
-		AreaCode:         areaCode,
-		LocalPhoneNumber: localNumber,
-	}, nil
+	return buildResponse(region, remainingNumber), nil
 }
 
 func processNumberWithoutCountryCode(phoneNumber, countryCode string) (*PhoneNumberResponse, *ErrorResponse) {
@@ -75,8 +137,8 @@ func processNumberWithoutCountryCode(phoneNumber, countryCode string) (*PhoneNum
 		}
 	}
 
-	// Get dial code for country
-	dialCode, exists := CountryCodeMap[strings.ToUpper(countryCode)]
+	// Get the metadata for this country
+	region, exists := lookupRegion(countryCode)
 	if !exists {
 		return nil, &ErrorResponse{
 			PhoneNumber: phoneNumber,
@@ -84,61 +146,169 @@ func processNumberWithoutCountryCode(phoneNumber, countryCode string) (*PhoneNum
 		}
 	}
 
-	// Get area code length for this country
-	areaCodeLength, exists := AreaCodeMap[strings.ToUpper(countryCode)]
-	if !exists {
+	// National numbers are often written with the trunk prefix, which isn't part of the E.164 number
+	nationalNumber := region.stripTrunkPrefix(cleanNumber)
+
+	// Make sure what's left is a real number in this country
+	if reason := region.validateNationalNumber(nationalNumber); reason != "" {
 		return nil, &ErrorResponse{
 			PhoneNumber: phoneNumber,
-			Error:       map[string]string{"countryCode": "unsupported country"},
+			Error:       map[string]string{"phoneNumber": reason},
 		}
 	}
 
+	return buildResponse(region, nationalNumber), nil
+}
+
+// Builds the response from the national number (everything after the dial code)
+func buildResponse(region *RegionMetadata, nationalNumber string) *PhoneNumberResponse {
 	// Extract area code and local number
-	var areaCode, localNumber string
-	if areaCodeLength > 0 && len(cleanNumber) > areaCodeLength {
-		areaCode = cleanNumber[:areaCodeLength]
-		localNumber = cleanNumber[areaCodeLength:]
-	} else {
-		areaCode = ""
-		localNumber = cleanNumber
-	}
+	areaCode, localNumber := region.splitAreaCode(nationalNumber)
 
 	// Reconstruct full phone number with +
-	fullPhoneNumber := "+" + dialCode + cleanNumber
+	fullPhoneNumber := "+" + region.DialCode + nationalNumber
 
 	return &PhoneNumberResponse{
 		PhoneNumber:      fullPhoneNumber,
-		CountryCode:      strings.ToUpper(countryCode),
+		CountryCode:      region.CountryCode,
 		AreaCode:         areaCode,
 		LocalPhoneNumber: localNumber,
-	}, nil
+		NumberType:       string(region.numberType(nationalNumber)),
+		Formats:          region.formatNumber(nationalNumber),
+	}
 }
 
+// Parses a phone number with the default options (strict mode, warn on conflicts)
 func parsePhoneNumber(phoneNumber, countryCode string) (*PhoneNumberResponse, *ErrorResponse) {
+	return parsePhoneNumberWithOptions(phoneNumber, countryCode, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+}
+
+func parsePhoneNumberWithOptions(phoneNumber, countryCode string, options ParseOptions) (*PhoneNumberResponse, *ErrorResponse) {
+	// Numbers copied from Japanese or Arabic interfaces i.e. ＋８１ or ٢١٢ are turned into ASCII first
+	normalized := normalizeUnicode(phoneNumber)
+
+	// tel: URIs carry the extension and sub-address as parameters. Business numbers often have an extension written
+	// on the end instead i.e. +1 212 569 0123 x123, either way it's kept aside while we parse the rest
+	var number, extension, subAddress string
+	if isTelURI(normalized) {
+		uri, message := parseTelURI(normalized)
+		if message != "" {
+			return nil, &ErrorResponse{
+				PhoneNumber: phoneNumber,
+				Error:       map[string]string{"phoneNumber": message},
+			}
+		}
+		number, extension, subAddress = uri.Number, uri.Extension, uri.SubAddress
+	} else {
+		number, extension = splitExtension(normalized)
+	}
+
+	// Vanity numbers like 1-800-FLOWERS are spelled out on the keypad and always come with punctuation
+	var vanityNumber string
+	if options.AllowVanity && isVanityNumber(number) {
+		vanityNumber = phoneNumber
+		number = stripSeparators(convertVanityNumber(number))
+	}
+
+	// Lenient mode throws away punctuation like (212) 569-0123 before validating
+	if options.Mode == ModeLenient {
+		number = stripSeparators(number)
+	}
+
 	// Validate the phone number format
-	if !validatePhoneNumberFormat(phoneNumber) {
+	if !validatePhoneNumberFormat(number) {
 		return nil, &ErrorResponse{
 			PhoneNumber: phoneNumber,
 			Error:       map[string]string{"phoneNumber": "invalid format"},
 		}
 	}
 
+	// An international call prefix (i.e. 00 or 011) works the same as a +. countryCode is then the country it was
+	// dialled from, not the country of the number
+	number, dialledInternationally := stripInternationalPrefix(number, countryCode)
+
 	// Validate spaces before any other processing
-	if !validateSpaces(phoneNumber) {
+	if !validateSpaces(number) {
 		return nil, &ErrorResponse{
 			PhoneNumber: phoneNumber,
 			Error:       map[string]string{"phoneNumber": "invalid space placement"},
 		}
 	}
 
-	// Try to extract country code from numbr
-	extractedCountryCode, dialCode, hasCountryCodeInNumber := extractCountryCodeFromNumber(phoneNumber)
+	// Try to extract country code from numbr, unless it reads better as a national number for countryCode.
+	// A national NANP number can still turn out to be in another country from its area code
+	var extractedCountryCode, dialCode string
+	hasCountryCodeInNumber := false
+	interpretations := findInterpretations(number, countryCode)
+	if len(interpretations) > 0 {
+		extractedCountryCode, dialCode, hasCountryCodeInNumber = interpretations[0].countryCode, interpretations[0].dialCode, interpretations[0].international
+	}
+
+	// The caller told us the country but the number says otherwise
+	conflict := options.CountryCodeConflict != ConflictIgnore && !dialledInternationally && extractedCountryCode != "" && countryCode != "" && !strings.EqualFold(countryCode, extractedCountryCode)
+	if conflict && options.CountryCodeConflict == ConflictReject {
+		return nil, &ErrorResponse{
+			PhoneNumber: phoneNumber,
+			Error:       map[string]string{"countryCode": "conflicts with phone number country " + extractedCountryCode},
+		}
+	}
 
+	var result *PhoneNumberResponse
+	var errorResp *ErrorResponse
 	if hasCountryCodeInNumber {
-		return processNumberWithCountryCode(phoneNumber, dialCode, extractedCountryCode)
+		result, errorResp = processNumberWithCountryCode(number, dialCode, extractedCountryCode)
+	} else if extractedCountryCode != "" {
+		result, errorResp = processNumberWithoutCountryCode(number, extractedCountryCode)
 	} else {
-		return processNumberWithoutCountryCode(phoneNumber, countryCode)
+		result, errorResp = processNumberWithoutCountryCode(number, countryCode)
+	}
+	if errorResp == nil && conflict {
+		result.Warnings = append(result.Warnings, Warning{
+			Field:   "countryCode",
+			Message: "conflicts with phone number country " + extractedCountryCode + ", ignored " + strings.ToUpper(countryCode),
+		})
+	}
+
+	// Now we know where the country code, area code and local number are we can check the spaces line up with them
+	if errorResp == nil {
+		if part := validateSpaceBoundaries(number, result, hasCountryCodeInNumber); part != "" {
+			result, errorResp = nil, &ErrorResponse{
+				Error: map[string]string{"phoneNumber": "invalid space placement within " + part},
+			}
+		}
+	}
+
+	// Both readings of the digits are valid numbers, let the caller know we had to pick one
+	if errorResp == nil && len(interpretations) > 1 && interpretations[1].valid {
+		other := interpretations[1]
+		result.Warnings = append(result.Warnings, Warning{
+			Field:   "phoneNumber",
+			Message: "ambiguous, could also be " + other.e164() + " (" + other.countryCode + ")",
+		})
+	}
+
+	// Give the caller every way the number could be read so they can ask the user which one they meant
+	if errorResp == nil {
+		result.Candidates = rankCandidates(interpretations)
 	}
+
+	if errorResp == nil {
+		result.VanityNumber = vanityNumber
+	}
+	if errorResp == nil && extension != "" {
+		result.Extension = extension
+		result.Formats.RFC3966 += ";ext=" + extension
+	}
+	if errorResp == nil && subAddress != "" {
+		result.SubAddress = subAddress
+		result.Formats.RFC3966 += ";isub=" + url.PathEscape(subAddress)
+	}
+
+	// Errors always echo what the caller sent us
+	if errorResp != nil {
+		errorResp.PhoneNumber = phoneNumber
+	}
+	return result, errorResp
 }
 
 func phoneNumberHandler(c *gin.Context) {
@@ -153,7 +323,14 @@ func phoneNumberHandler(c *gin.Context) {
 		return
 	}
 
-	result, errorResp := parsePhoneNumber(phoneNumber, countryCode)
+	options, errorResp := parseOptionsFromQuery(c)
+	if errorResp != nil {
+		errorResp.PhoneNumber = phoneNumber
+		c.JSON(http.StatusBadRequest, errorResp)
+		return
+	}
+
+	result, errorResp := parsePhoneNumberWithOptions(phoneNumber, countryCode, options)
 	if errorResp != nil {
 		c.JSON(http.StatusBadRequest, errorResp)
 		return
@@ -162,11 +339,389 @@ func phoneNumberHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+func extractHandler(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxExtractBodyBytes)
+
+	var request ExtractRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		if respondIfBodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"body": "invalid JSON"},
+		})
+		return
+	}
+
+	if request.Text == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"text": "required value is missing"},
+		})
+		return
+	}
+
+	// The country is optional but if it's given it has to be one we know
+	if request.CountryCode != "" {
+		if !validateCountryCodeMeetsISO_3166_1_alpha_2(request.CountryCode) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: map[string]string{"countryCode": "invalid format"},
+			})
+			return
+		}
+		if _, exists := lookupRegion(request.CountryCode); !exists {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: map[string]string{"countryCode": "unsupported country"},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, ExtractResponse{
+		PhoneNumbers: extractPhoneNumbers(request.Text, request.CountryCode),
+	})
+}
+
+func formatAsYouTypeHandler(c *gin.Context) {
+	digits := c.Query("digits")
+	countryCode := c.Query("countryCode")
+
+	if digits == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"digits": "required parameter is missing"},
+		})
+		return
+	}
+
+	// Nothing longer than this can be a phone number however it's typed
+	if utf8.RuneCountInString(digits) > maxAsYouTypeLength || countDigits(normalizeUnicode(digits)) > maxAsYouTypeDigits {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			PhoneNumber: digits,
+			Error:       map[string]string{"digits": "too long"},
+		})
+		return
+	}
+
+	if countryCode != "" {
+		if !validateCountryCodeMeetsISO_3166_1_alpha_2(countryCode) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				PhoneNumber: digits,
+				Error:       map[string]string{"countryCode": "invalid format"},
+			})
+			return
+		}
+		if _, exists := lookupRegion(countryCode); !exists {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				PhoneNumber: digits,
+				Error:       map[string]string{"countryCode": "unsupported country"},
+			})
+			return
+		}
+	}
+
+	// Type the digits in one at a time the same way a form would
+	formatter := newAsYouTypeFormatter(countryCode)
+	var formatted string
+	for _, r := range digits {
+		formatted = formatter.inputDigit(r)
+	}
+
+	c.JSON(http.StatusOK, AsYouTypeResponse{
+		Formatted:   formatted,
+		CountryCode: formatter.country(),
+	})
+}
+
+// Handles the custom methods on the phone numbers collection i.e. /v1/phone-numbers:batch
+// gin reads the colon as the start of a path parameter so we match the method name ourselves,
+// the parameter keeps the colon so /v1/phone-numbersbatch doesn't match
+func phoneNumbersMethodHandler(c *gin.Context) {
+	switch c.Param("method") {
+	case ":batch":
+		batchHandler(c)
+	case ":stream":
+		streamHandler(c)
+	case ":normalizeCsv":
+		normalizeCSVHandler(c)
+	default:
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
+// Responds with a body error if err is from a body cut off by http.MaxBytesReader
+func respondIfBodyTooLarge(c *gin.Context, err error) bool {
+	tooLarge := new(http.MaxBytesError)
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+
+	c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+		Error: map[string]string{"body": fmt.Sprintf("too large, the maximum is %d bytes", tooLarge.Limit)},
+	})
+	return true
+}
+
+func batchHandler(c *gin.Context) {
+	// A body too big for maxBatchItems is turned away before we decode all of it
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxBatchItems)*maxBatchItemBytes)
+
+	var items []BatchItem
+	if err := c.ShouldBindJSON(&items); err != nil {
+		if respondIfBodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"body": "invalid JSON"},
+		})
+		return
+	}
+
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"body": "required value is missing"},
+		})
+		return
+	}
+
+	if len(items) > maxBatchItems {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"body": fmt.Sprintf("too many items, the maximum is %d", maxBatchItems)},
+		})
+		return
+	}
+
+	// The options in the query string apply to every item
+	options, errorResp := parseOptionsFromQuery(c)
+	if errorResp != nil {
+		c.JSON(http.StatusBadRequest, errorResp)
+		return
+	}
+
+	c.JSON(http.StatusOK, parseBatch(items, options))
+}
+
+func streamHandler(c *gin.Context) {
+	// The options in the query string apply to every line
+	options, errorResp := parseOptionsFromQuery(c)
+	if errorResp != nil {
+		c.JSON(http.StatusBadRequest, errorResp)
+		return
+	}
+
+	// HTTP/1 stops us reading the request once we start the response unless we ask, not every server can do it
+	// but then the caller just has to send the whole body before they see any results
+	_ = http.NewResponseController(c.Writer).EnableFullDuplex()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+
+	if err := streamPhoneNumbers(c.Request.Body, c.Writer, options, c.Writer.Flush); err != nil {
+		c.Error(err)
+	}
+}
+
+// Takes an uploaded CSV and sends it back with the number in each row parsed into extra columns
+func normalizeCSVHandler(c *gin.Context) {
+	options, errorResp := parseOptionsFromQuery(c)
+	if errorResp != nil {
+		c.JSON(http.StatusBadRequest, errorResp)
+		return
+	}
+
+	upload, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"file": "required value is missing"},
+		})
+		return
+	}
+
+	numberColumn := c.PostForm("numberColumn")
+	if numberColumn == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"numberColumn": "required value is missing"},
+		})
+		return
+	}
+
+	file, err := upload.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"file": "could not be read"},
+		})
+		return
+	}
+	defer file.Close()
+
+	records, errorResp := normalizeCSV(file, numberColumn, c.PostForm("countryColumn"), options)
+	if errorResp != nil {
+		c.JSON(http.StatusBadRequest, errorResp)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": normalizedCSVFilename(upload.Filename)}))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.WriteAll(records); err != nil {
+		c.Error(err)
+	}
+}
+
+// Takes an uploaded CSV or NDJSON file and starts a job to parse every row of it
+func (store *JobStore) submitHandler(c *gin.Context) {
+	// The options in the query string apply to every row
+	options, errorResp := parseOptionsFromQuery(c)
+	if errorResp != nil {
+		c.JSON(http.StatusBadRequest, errorResp)
+		return
+	}
+
+	upload, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"file": "required value is missing"},
+		})
+		return
+	}
+
+	// The format can be given outright, otherwise the file name tells us
+	format := c.DefaultPostForm("format", jobFormatFromFilename(upload.Filename))
+	if format != JobFormatCSV && format != JobFormatNDJSON {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"format": "invalid value"},
+		})
+		return
+	}
+
+	file, err := upload.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: map[string]string{"file": "could not be read"},
+		})
+		return
+	}
+	defer file.Close()
+
+	job, err := store.submit(file, format, options)
+	if errors.Is(err, errJobQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error: map[string]string{"job": err.Error()},
+		})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: map[string]string{"job": "could not be saved"},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (store *JobStore) statusHandler(c *gin.Context) {
+	job, exists := store.get(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: map[string]string{"id": "job not found"},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// Sends the job's results as NDJSON, one line per row in the same shape as the :stream endpoint
+func (store *JobStore) resultsHandler(c *gin.Context) {
+	job, exists := store.get(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: map[string]string{"id": "job not found"},
+		})
+		return
+	}
+
+	if job.Status != JobDone {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error: map[string]string{"status": "job is " + string(job.Status)},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.File(store.resultsPath(job.ID))
+}
+
+// Reads a whole number setting from the environment, using fallback if it isn't set
+func intFromEnv(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("%s must be a whole number of at least 1, got %q", name, value)
+	}
+	return number, nil
+}
+
 func main() {
+	defaultJobsDir := os.Getenv("PHONE_JOBS_DIR")
+	if defaultJobsDir == "" {
+		defaultJobsDir = "jobs"
+	}
+
+	defaultBatchWorkers, err := intFromEnv("PHONE_BATCH_WORKERS", batchWorkers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defaultMaxBatchItems, err := intFromEnv("PHONE_BATCH_MAX_ITEMS", maxBatchItems)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	metadataPath := flag.String("metadata", os.Getenv("PHONE_METADATA_PATH"), "path to libphonenumber's PhoneNumberMetadata.xml")
+	jobsDir := flag.String("jobs", defaultJobsDir, "directory bulk jobs are kept in")
+	flag.IntVar(&batchWorkers, "batch-workers", defaultBatchWorkers, "how many numbers in a batch are parsed at the same time")
+	flag.IntVar(&maxBatchItems, "batch-max-items", defaultMaxBatchItems, "most numbers allowed in one batch request")
+	flag.Parse()
+
+	if batchWorkers < 1 || maxBatchItems < 1 {
+		log.Fatalf("-batch-workers and -batch-max-items must be at least 1")
+	}
+
+	// Without a metadata file we fall back to the built-in selection of countries
+	if *metadataPath != "" {
+		regions, err := loadRegionMetadataFile(*metadataPath)
+		if err != nil {
+			log.Fatalf("Could not load metadata from %s: %v", *metadataPath, err)
+		}
+		installRegionMetadata(regions)
+		fmt.Printf("Loaded metadata for %d regions from %s\n", len(regions), *metadataPath)
+	}
+
+	// Jobs left over from before a restart pick up where they were
+	jobs, err := openJobStore(*jobsDir)
+	if err != nil {
+		log.Fatalf("Could not open jobs directory %s: %v", *jobsDir, err)
+	}
+	jobs.start(jobWorkers)
+
 	r := gin.Default()
 
 	// Add the phone numbers endpoint
 	r.GET("/v1/phone-numbers", phoneNumberHandler)
+	r.POST("/v1/phone-numbers/extract", extractHandler)
+	r.GET("/v1/phone-numbers/format-as-you-type", formatAsYouTypeHandler)
+	r.POST("/v1/phone-numbers:method", phoneNumbersMethodHandler)
+
+	// Add the bulk jobs endpoints
+	r.POST("/v1/jobs", jobs.submitHandler)
+	r.GET("/v1/jobs/:id", jobs.statusHandler)
+	r.GET("/v1/jobs/:id/results", jobs.resultsHandler)
 
 	// Start server on port 8080
 	fmt.Println("Server starting on port 8080...")
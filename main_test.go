@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -75,16 +76,29 @@ func TestProcessNumberWithCountryCode(t *testing.T) {
 			},
 		},
 		{
-			name:        "Short number without area code",
-			phoneNumber: "+81123456",
+			name:        "Japan number with one digit area code",
+			phoneNumber: "+81612345678",
 			dialCode:    "81",
 			countryCode: "JP",
 			expectError: false,
 			expected: &PhoneNumberResponse{
-				PhoneNumber:      "+81123456",
+				PhoneNumber:      "+81612345678",
 				CountryCode:      "JP",
-				AreaCode:         "1",
-				LocalPhoneNumber: "23456",
+				AreaCode:         "6",
+				LocalPhoneNumber: "12345678",
+			},
+		},
+		{
+			name:        "Valid UK number with two digit area code",
+			phoneNumber: "+44 20 79460018",
+			dialCode:    "44",
+			countryCode: "GB",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+442079460018",
+				CountryCode:      "GB",
+				AreaCode:         "20",
+				LocalPhoneNumber: "79460018",
 			},
 		},
 	}
@@ -157,6 +171,42 @@ func TestProcessNumberWithoutCountryCode(t *testing.T) {
 				LocalPhoneNumber: "3118150",
 			},
 		},
+		{
+			name:        "Valid UK national format with trunk prefix",
+			phoneNumber: "020 7946 0018",
+			countryCode: "GB",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+442079460018",
+				CountryCode:      "GB",
+				AreaCode:         "20",
+				LocalPhoneNumber: "79460018",
+			},
+		},
+		{
+			name:        "Valid France national format with trunk prefix",
+			phoneNumber: "0612345678",
+			countryCode: "FR",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+33612345678",
+				CountryCode:      "FR",
+				AreaCode:         "6",
+				LocalPhoneNumber: "12345678",
+			},
+		},
+		{
+			name:        "Valid US long distance format with trunk prefix",
+			phoneNumber: "1 212 5690123",
+			countryCode: "US",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+12125690123",
+				CountryCode:      "US",
+				AreaCode:         "212",
+				LocalPhoneNumber: "5690123",
+			},
+		},
 		{
 			name:        "Missing country code",
 			phoneNumber: "2125690123",
@@ -261,6 +311,7 @@ func TestParsePhoneNumber(t *testing.T) {
 				CountryCode:      "US",
 				AreaCode:         "212",
 				LocalPhoneNumber: "5690123",
+				NumberType:       "FIXED_LINE_OR_MOBILE",
 			},
 		},
 		{
@@ -288,6 +339,42 @@ func TestParsePhoneNumber(t *testing.T) {
 				LocalPhoneNumber: "3118150",
 			},
 		},
+		{
+			name:        "Valid Canada number",
+			phoneNumber: "+1 416 5550123",
+			countryCode: "",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+14165550123",
+				CountryCode:      "CA",
+				AreaCode:         "416",
+				LocalPhoneNumber: "5550123",
+			},
+		},
+		{
+			name:        "Valid with 00 international prefix",
+			phoneNumber: "0034 915 872200",
+			countryCode: "",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+34915872200",
+				CountryCode:      "ES",
+				AreaCode:         "915",
+				LocalPhoneNumber: "872200",
+			},
+		},
+		{
+			name:        "Valid with 011 international prefix from the US",
+			phoneNumber: "011 52 631 3118150",
+			countryCode: "US",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+526313118150",
+				CountryCode:      "MX",
+				AreaCode:         "631",
+				LocalPhoneNumber: "3118150",
+			},
+		},
 		{
 			name:        "Invalid format with letters",
 			phoneNumber: "abc123",
@@ -297,12 +384,76 @@ func TestParsePhoneNumber(t *testing.T) {
 			errorValue:  "invalid format",
 		},
 		{
-			name:        "Invalid space placement",
-			phoneNumber: "351 21 094 2000",
+			name:        "International written the country's way",
+			phoneNumber: "+44 20 7946 0018",
+			countryCode: "",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+442079460018",
+				CountryCode:      "GB",
+				AreaCode:         "20",
+				LocalPhoneNumber: "79460018",
+			},
+		},
+		{
+			name:        "Full-width international written the country's way",
+			phoneNumber: "＋１ ２１２ ５６９ ０１２３",
+			countryCode: "",
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+12125690123",
+				CountryCode:      "US",
+				AreaCode:         "212",
+				LocalPhoneNumber: "5690123",
+			},
+		},
+		{
+			name:        "Space in the wrong place",
+			phoneNumber: "351 21 0942 000",
+			countryCode: "",
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "invalid space placement within local phone number",
+		},
+		{
+			name:        "Space inside area code",
+			phoneNumber: "+12 125690123",
+			countryCode: "",
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "invalid space placement within area code",
+		},
+		{
+			name:        "Space inside local number",
+			phoneNumber: "+1212 5690 123",
+			countryCode: "",
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "invalid space placement within local phone number",
+		},
+		{
+			name:        "Too short for the country",
+			phoneNumber: "+1212",
 			countryCode: "",
 			expectError: true,
 			errorKey:    "phoneNumber",
-			errorValue:  "invalid space placement",
+			errorValue:  "too short",
+		},
+		{
+			name:        "Too long for the country",
+			phoneNumber: "+44123456789012345",
+			countryCode: "",
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "too long",
+		},
+		{
+			name:        "Invalid digits for the country",
+			phoneNumber: "2120690123",
+			countryCode: "US",
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "invalid for region",
 		},
 		{
 			name:        "Missing country code",
@@ -343,6 +494,337 @@ func TestParsePhoneNumber(t *testing.T) {
 				if result.LocalPhoneNumber != tt.expected.LocalPhoneNumber {
 					t.Errorf("Expected localPhoneNumber %s, got %s", tt.expected.LocalPhoneNumber, result.LocalPhoneNumber)
 				}
+				if tt.expected.NumberType != "" && result.NumberType != tt.expected.NumberType {
+					t.Errorf("Expected numberType %s, got %s", tt.expected.NumberType, result.NumberType)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePhoneNumberWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		countryCode string
+		options     ParseOptions
+		expectError bool
+		errorKey    string
+		errorValue  string
+		expected    *PhoneNumberResponse
+		warnings    []Warning
+	}{
+		{
+			name:        "Lenient with parentheses and dash",
+			phoneNumber: "(212) 569-0123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeLenient},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+12125690123",
+				CountryCode:      "US",
+				AreaCode:         "212",
+				LocalPhoneNumber: "5690123",
+			},
+		},
+		{
+			name:        "Lenient with dots",
+			phoneNumber: "212.569.0123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeLenient},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+12125690123",
+				CountryCode:      "US",
+				AreaCode:         "212",
+				LocalPhoneNumber: "5690123",
+			},
+		},
+		{
+			name:        "Lenient international with punctuation",
+			phoneNumber: "+49 (30) 123-4567",
+			countryCode: "",
+			options:     ParseOptions{Mode: ModeLenient},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+49301234567",
+				CountryCode:      "DE",
+				AreaCode:         "30",
+				LocalPhoneNumber: "1234567",
+			},
+		},
+		{
+			name:        "Lenient with slash and international prefix",
+			phoneNumber: "0049 30/1234567",
+			countryCode: "",
+			options:     ParseOptions{Mode: ModeLenient},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+49301234567",
+				CountryCode:      "DE",
+				AreaCode:         "30",
+				LocalPhoneNumber: "1234567",
+			},
+		},
+		{
+			name:        "Lenient still rejects letters",
+			phoneNumber: "(212) ABC-0123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeLenient},
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "invalid format",
+		},
+		{
+			name:        "Conflicting country code is rejected",
+			phoneNumber: "+34915872200",
+			countryCode: "MX",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: true,
+			errorKey:    "countryCode",
+			errorValue:  "conflicts with phone number country ES",
+		},
+		{
+			name:        "Conflicting country code is a warning",
+			phoneNumber: "+34915872200",
+			countryCode: "mx",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+34915872200",
+				CountryCode:      "ES",
+				AreaCode:         "915",
+				LocalPhoneNumber: "872200",
+			},
+			warnings: []Warning{{Field: "countryCode", Message: "conflicts with phone number country ES, ignored MX"}},
+		},
+		{
+			name:        "Conflicting NANP country",
+			phoneNumber: "+14165550123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: true,
+			errorKey:    "countryCode",
+			errorValue:  "conflicts with phone number country CA",
+		},
+		{
+			name:        "National NANP number in another country",
+			phoneNumber: "4165550123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+14165550123",
+				CountryCode:      "CA",
+				AreaCode:         "416",
+				LocalPhoneNumber: "5550123",
+			},
+			warnings: []Warning{{Field: "countryCode", Message: "conflicts with phone number country CA, ignored US"}},
+		},
+		{
+			name:        "National NANP number in the US",
+			phoneNumber: "2125690123",
+			countryCode: "ca",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+12125690123",
+				CountryCode:      "US",
+				AreaCode:         "212",
+				LocalPhoneNumber: "5690123",
+			},
+			warnings: []Warning{{Field: "countryCode", Message: "conflicts with phone number country US, ignored CA"}},
+		},
+		{
+			name:        "Conflicting national NANP country",
+			phoneNumber: "4165550123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: true,
+			errorKey:    "countryCode",
+			errorValue:  "conflicts with phone number country CA",
+		},
+		{
+			name:        "National NANP number in the country given",
+			phoneNumber: "4165550123",
+			countryCode: "CA",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+14165550123",
+				CountryCode:      "CA",
+				AreaCode:         "416",
+				LocalPhoneNumber: "5550123",
+			},
+		},
+		{
+			name:        "Dialled from the country code is not a conflict",
+			phoneNumber: "011 52 631 3118150",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+526313118150",
+				CountryCode:      "MX",
+				AreaCode:         "631",
+				LocalPhoneNumber: "3118150",
+			},
+		},
+		{
+			name:        "Dialled from the country code is not rejected",
+			phoneNumber: "011 52 631 3118150",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+526313118150",
+				CountryCode:      "MX",
+				AreaCode:         "631",
+				LocalPhoneNumber: "3118150",
+			},
+		},
+		{
+			name:        "Dialled from Spain is not rejected",
+			phoneNumber: "0052 631 3118150",
+			countryCode: "ES",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+526313118150",
+				CountryCode:      "MX",
+				AreaCode:         "631",
+				LocalPhoneNumber: "3118150",
+			},
+		},
+		{
+			name:        "Matching country code",
+			phoneNumber: "+34915872200",
+			countryCode: "ES",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+34915872200",
+				CountryCode:      "ES",
+				AreaCode:         "915",
+				LocalPhoneNumber: "872200",
+			},
+		},
+		{
+			name:        "Plus-less digits read as national for the country code",
+			phoneNumber: "1632960001",
+			countryCode: "GB",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictReject},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+441632960001",
+				CountryCode:      "GB",
+				AreaCode:         "1632",
+				LocalPhoneNumber: "960001",
+			},
+		},
+		{
+			name:        "Plus-less digits only valid as international",
+			phoneNumber: "34915872200",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+34915872200",
+				CountryCode:      "ES",
+				AreaCode:         "915",
+				LocalPhoneNumber: "872200",
+			},
+			warnings: []Warning{{Field: "countryCode", Message: "conflicts with phone number country ES, ignored US"}},
+		},
+		{
+			name:        "Plus-less digits valid both ways",
+			phoneNumber: "4930123456",
+			countryCode: "DE",
+			options:     ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn},
+			expectError: false,
+			expected: &PhoneNumberResponse{
+				PhoneNumber:      "+494930123456",
+				CountryCode:      "DE",
+				AreaCode:         "4930",
+				LocalPhoneNumber: "123456",
+			},
+			warnings: []Warning{{Field: "phoneNumber", Message: "ambiguous, could also be +4930123456 (DE)"}},
+		},
+		{
+			name:        "Strict rejects punctuation",
+			phoneNumber: "(212) 569-0123",
+			countryCode: "US",
+			options:     ParseOptions{Mode: ModeStrict},
+			expectError: true,
+			errorKey:    "phoneNumber",
+			errorValue:  "invalid format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errorResp := parsePhoneNumberWithOptions(tt.phoneNumber, tt.countryCode, tt.options)
+
+			if tt.expectError {
+				if errorResp == nil {
+					t.Errorf("Expected error but got none")
+					return
+				}
+				if errorResp.Error[tt.errorKey] != tt.errorValue {
+					t.Errorf("Expected error %s: %s, got %s: %s", tt.errorKey, tt.errorValue, tt.errorKey, errorResp.Error[tt.errorKey])
+				}
+				if errorResp.PhoneNumber != tt.phoneNumber {
+					t.Errorf("Expected error phoneNumber %s, got %s", tt.phoneNumber, errorResp.PhoneNumber)
+				}
+			} else {
+				if errorResp != nil {
+					t.Errorf("Expected no error but got %v", errorResp.Error)
+					return
+				}
+				if result.PhoneNumber != tt.expected.PhoneNumber {
+					t.Errorf("Expected phoneNumber %s, got %s", tt.expected.PhoneNumber, result.PhoneNumber)
+				}
+				if result.CountryCode != tt.expected.CountryCode {
+					t.Errorf("Expected countryCode %s, got %s", tt.expected.CountryCode, result.CountryCode)
+				}
+				if result.AreaCode != tt.expected.AreaCode {
+					t.Errorf("Expected areaCode %s, got %s", tt.expected.AreaCode, result.AreaCode)
+				}
+				if result.LocalPhoneNumber != tt.expected.LocalPhoneNumber {
+					t.Errorf("Expected localPhoneNumber %s, got %s", tt.expected.LocalPhoneNumber, result.LocalPhoneNumber)
+				}
+				if !reflect.DeepEqual(result.Warnings, tt.warnings) {
+					t.Errorf("Expected warnings %v, got %v", tt.warnings, result.Warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestIntFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    int
+		expectError bool
+	}{
+		{"Not set", "", 8, false},
+		{"Set", "16", 16, false},
+		{"Not a number", "lots", 0, true},
+		{"Zero", "0", 0, true},
+		{"Negative", "-4", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PHONE_TEST_SETTING", tt.value)
+
+			result, err := intFromEnv("PHONE_TEST_SETTING", 8)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, result)
 			}
 		})
 	}
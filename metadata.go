@@ -0,0 +1,510 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RegionMetadata holds everything we know about numbering in a single region.
+// Instead of adding another map every time we need a new piece of data we add a field here
+type RegionMetadata struct {
+	CountryCode         string         // ISO 3166-1 alpha-2 code i.e. US
+	DialCode            string         // Country calling code i.e. 1 for US
+	AreaCodeLengths     map[string]int // Area code length by leading digits, see areaCodeLength
+	PossibleLengths     []int          // Possible lengths of the national number (everything after the dial code)
+	TrunkPrefix         string         // National (trunk) prefix dialled before the area code i.e. 0 in GB
+	InternationalPrefix string         // Prefix dialled to call out of the region i.e. 011 in US, can be a pattern
+	Formats             []NumberFormat // Formatting patterns for national numbers
+
+	NationalNumberPattern string // Pattern every valid national number in the region matches
+	MainCountryForCode    bool   // Preferred region when several share a dial code i.e. US for 1
+
+	NumberTypes map[NumberType]string // Pattern for each type of number in the region, see numbertype.go
+
+	areaCodeTrie        *prefixTrie[int] // Built from AreaCodeLengths by prepare
+	internationalPrefix *regexp.Regexp   // Compiled from InternationalPrefix by prepare
+	nationalPattern     *regexp.Regexp   // Compiled from NationalNumberPattern by prepare
+	numberTypePatterns  map[NumberType]*regexp.Regexp
+}
+
+// NumberFormat describes how a national number is grouped for display.
+// Pattern splits the national number into groups which Format then references as $1, $2...
+type NumberFormat struct {
+	Pattern       string // i.e. (\d{3})(\d{3})(\d{4})
+	LeadingDigits string // Optional pattern the start of the national number has to match
+	Format        string // National format i.e. ($1) $2-$3
+	IntlFormat    string // International format, same as Format when empty
+
+	pattern       *regexp.Regexp
+	leadingDigits *regexp.Regexp
+}
+
+// Area code and exchange can't start with 0 or 1 anywhere in the North American Numbering Plan
+const nanpPattern = `[2-9]\d{2}[2-9]\d{6}`
+
+// NANP doesn't separate fixed lines from mobiles, only the special service area codes stand out
+var nanpNumberTypes = map[NumberType]string{
+	FixedLine:      nanpPattern,
+	Mobile:         nanpPattern,
+	TollFree:       `8(?:00|33|44|55|66|77|88)[2-9]\d{6}`,
+	PremiumRate:    `900[2-9]\d{6}`,
+	PersonalNumber: `5(?:00|2[1-9]|33|44|66|77|88)[2-9]\d{6}`,
+}
+
+// Formats shared by every region in the North American Numbering Plan
+var nanpFormats = []NumberFormat{
+	{Pattern: `(\d{3})(\d{3})(\d{4})`, Format: "($1) $2-$3", IntlFormat: "$1-$2-$3"},
+}
+
+// RegionMetadataMap maps country codes to their numbering metadata
+// Still only a small selection of the entire set, same as before
+var RegionMetadataMap = map[string]*RegionMetadata{
+	"US": {
+		CountryCode:           "US",
+		DialCode:              "1",
+		AreaCodeLengths:       map[string]int{"": 3}, // i.e 212 for Manhattan
+		PossibleLengths:       []int{10},
+		NationalNumberPattern: nanpPattern,
+		NumberTypes:           nanpNumberTypes,
+		TrunkPrefix:           "1",
+		InternationalPrefix:   "011",
+		Formats:               nanpFormats,
+		MainCountryForCode:    true,
+	},
+	"CA": nanpRegion("CA"),
+	"AG": nanpRegion("AG"),
+	"AI": nanpRegion("AI"),
+	"AS": nanpRegion("AS"),
+	"BB": nanpRegion("BB"),
+	"BM": nanpRegion("BM"),
+	"BS": nanpRegion("BS"),
+	"DM": nanpRegion("DM"),
+	"DO": nanpRegion("DO"),
+	"GD": nanpRegion("GD"),
+	"GU": nanpRegion("GU"),
+	"JM": nanpRegion("JM"),
+	"KN": nanpRegion("KN"),
+	"KY": nanpRegion("KY"),
+	"LC": nanpRegion("LC"),
+	"MP": nanpRegion("MP"),
+	"MS": nanpRegion("MS"),
+	"PR": nanpRegion("PR"),
+	"SX": nanpRegion("SX"),
+	"TC": nanpRegion("TC"),
+	"TT": nanpRegion("TT"),
+	"VC": nanpRegion("VC"),
+	"VG": nanpRegion("VG"),
+	"VI": nanpRegion("VI"),
+	"MX": {
+		CountryCode:           "MX",
+		DialCode:              "52",
+		AreaCodeLengths:       map[string]int{"": 3, "33": 2, "55": 2, "56": 2, "81": 2}, // Guadalajara, Mexico City and Monterrey have 2 digits
+		PossibleLengths:       []int{10},
+		NationalNumberPattern: `[1-9]\d{9}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `[1-9]\d{9}`,
+			Mobile:         `[1-9]\d{9}`,
+			TollFree:       `8(?:00|88)\d{7}`,
+			PremiumRate:    `900\d{7}`,
+			PersonalNumber: `500\d{7}`,
+		},
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{2})(\d{4})(\d{4})`, LeadingDigits: "33|5[56]|81", Format: "$1 $2 $3"},
+			{Pattern: `(\d{3})(\d{3})(\d{4})`, Format: "$1 $2 $3"},
+		},
+	},
+	"ES": {
+		CountryCode:           "ES",
+		DialCode:              "34",
+		AreaCodeLengths:       map[string]int{"": 3},
+		PossibleLengths:       []int{9},
+		NationalNumberPattern: `[5-9]\d{8}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `[89][1-9]\d{7}`,
+			Mobile:         `(?:6\d|7[1-48])\d{7}`,
+			TollFree:       `[89]00\d{6}`,
+			PremiumRate:    `80[367]\d{6}|90[3-7]\d{6}`,
+			SharedCost:     `90[12]\d{6}`,
+			PersonalNumber: `70\d{7}`,
+			Uan:            `51\d{7}`,
+		},
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{3})(\d{2})(\d{2})(\d{2})`, LeadingDigits: "[89]", Format: "$1 $2 $3 $4"},
+			{Pattern: `(\d{3})(\d{3})(\d{3})`, Format: "$1 $2 $3"},
+		},
+	},
+	"PT": {
+		CountryCode:           "PT",
+		DialCode:              "351",
+		AreaCodeLengths:       map[string]int{"": 2, "2": 3, "21": 2, "22": 2, "8": 3}, // Lisbon and Porto have 2 digits, the rest of the country 3
+		PossibleLengths:       []int{9},
+		NationalNumberPattern: `[2-9]\d{8}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `2\d{8}`,
+			Mobile:         `9[1236]\d{7}`,
+			TollFree:       `800\d{6}`,
+			PremiumRate:    `6(?:0[178]|4[68])\d{6}|760\d{6}`,
+			SharedCost:     `80[89]\d{6}`,
+			PersonalNumber: `884\d{6}`,
+			Voip:           `30\d{7}`,
+			Uan:            `70[07]\d{6}`,
+		},
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{2})(\d{3})(\d{4})`, LeadingDigits: "2[12]", Format: "$1 $2 $3"},
+			{Pattern: `(\d{3})(\d{3})(\d{3})`, Format: "$1 $2 $3"},
+		},
+	},
+	"GB": {
+		CountryCode: "GB",
+		DialCode:    "44",
+		// Mostly 4 digits, London and a few other cities use 2 or 3 and some rural areas 5
+		AreaCodeLengths: map[string]int{
+			"": 4, "2": 2, "3": 3, "8": 3, "9": 3,
+			"113": 3, "114": 3, "115": 3, "116": 3, "117": 3, "118": 3,
+			"121": 3, "131": 3, "141": 3, "151": 3, "161": 3, "171": 3, "181": 3, "191": 3,
+			"13873": 5, "15242": 5, "15394": 5, "15395": 5, "15396": 5, "16973": 5,
+			"16974": 5, "17683": 5, "17684": 5, "17687": 5, "19467": 5,
+		},
+		PossibleLengths:       []int{9, 10},
+		NationalNumberPattern: `1\d{8,9}|[23579]\d{9}|8\d{8,9}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `[12]\d{8,9}`,
+			Mobile:         `7(?:[1-57-9]\d{8}|624\d{6})`,
+			Pager:          `76(?:[013-9]\d|2[0-35-9])\d{6}`,
+			PersonalNumber: `70\d{8}`,
+			TollFree:       `80[08]\d{7}|800\d{6}`,
+			PremiumRate:    `9[018]\d{8}`,
+			SharedCost:     `8(?:4[2-5]|70)\d{7}`,
+			Voip:           `56\d{8}`,
+			Uan:            `(?:3[0347]|55)\d{8}`,
+		},
+		TrunkPrefix:         "0",
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{2})(\d{4})(\d{4})`, LeadingDigits: "2", Format: "0$1 $2 $3", IntlFormat: "$1 $2 $3"},
+			{Pattern: `(\d{3})(\d{3})(\d{4})`, LeadingDigits: `1(?:1|\d1)|3|8|9`, Format: "0$1 $2 $3", IntlFormat: "$1 $2 $3"},
+			{Pattern: `(\d{4})(\d{5,6})`, Format: "0$1 $2", IntlFormat: "$1 $2"},
+		},
+	},
+	"FR": {
+		CountryCode:           "FR",
+		DialCode:              "33",
+		AreaCodeLengths:       map[string]int{"": 1},
+		PossibleLengths:       []int{9},
+		NationalNumberPattern: `[1-9]\d{8}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:   `[1-5]\d{8}`,
+			Mobile:      `(?:6\d|7[3-9])\d{7}`,
+			TollFree:    `80[0-5]\d{6}`,
+			PremiumRate: `89[1-37-9]\d{6}`,
+			SharedCost:  `8(?:1[019]|2[0156]|84|90)\d{6}`,
+			Voip:        `9\d{8}`,
+			Uan:         `806\d{6}`,
+		},
+		TrunkPrefix:         "0",
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d)(\d{2})(\d{2})(\d{2})(\d{2})`, Format: "0$1 $2 $3 $4 $5", IntlFormat: "$1 $2 $3 $4 $5"},
+		},
+	},
+	"DE": {
+		CountryCode: "DE",
+		DialCode:    "49",
+		// 2 digits for the largest cities, 3 for other cities and mobile networks and 4 for everywhere else
+		AreaCodeLengths: map[string]int{
+			"": 4, "30": 2, "40": 2, "69": 2, "89": 2, "15": 3, "16": 3, "17": 3,
+			"180": 3, "700": 3, "800": 3, "900": 3,
+			"201": 3, "202": 3, "203": 3, "208": 3, "209": 3, "211": 3, "212": 3, "214": 3,
+			"221": 3, "228": 3, "231": 3, "234": 3, "241": 3, "251": 3, "261": 3, "271": 3,
+			"281": 3, "291": 3, "331": 3, "335": 3, "340": 3, "341": 3, "345": 3, "351": 3,
+			"355": 3, "361": 3, "365": 3, "371": 3, "375": 3, "381": 3, "385": 3, "391": 3,
+			"395": 3, "421": 3, "431": 3, "441": 3, "451": 3, "461": 3, "471": 3, "481": 3,
+			"511": 3, "521": 3, "531": 3, "541": 3, "551": 3, "561": 3, "571": 3, "581": 3,
+			"591": 3, "611": 3, "621": 3, "631": 3, "641": 3, "651": 3, "661": 3, "671": 3,
+			"681": 3, "711": 3, "721": 3, "731": 3, "741": 3, "751": 3, "761": 3, "771": 3,
+			"781": 3, "791": 3, "811": 3, "821": 3, "831": 3, "841": 3, "851": 3, "861": 3,
+			"871": 3, "906": 3, "911": 3, "921": 3, "931": 3, "941": 3, "951": 3, "961": 3,
+			"971": 3, "981": 3, "991": 3,
+		},
+		PossibleLengths:       []int{5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+		NationalNumberPattern: `[1-9]\d{4,14}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `[2-9]\d{4,14}`,
+			Mobile:         `1(?:5[0-25-9]\d{8}|6[023]\d{7,8}|7\d{8})`,
+			Pager:          `16(?:4\d{1,10}|[89]\d{1,11})`,
+			TollFree:       `800\d{7,12}`,
+			PremiumRate:    `900\d{7}`,
+			SharedCost:     `180\d{5,11}`,
+			PersonalNumber: `700\d{8}`,
+			Voip:           `32\d{9,11}`,
+			Uan:            `18[1-9]\d{5,11}`,
+			Voicemail:      `1(?:6(?:013|255|399)|7(?:(?:[015]1|[69]3)3|[2-4]55|[78]99))\d{7,8}|15(?:(?:[03-68]00|113)\d|2\d55|7\d99|9\d33)\d{7}`,
+		},
+		TrunkPrefix:         "0",
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{3})(\d{7,8})`, LeadingDigits: "1[5-7]", Format: "0$1 $2", IntlFormat: "$1 $2"},
+			{Pattern: `(\d{2})(\d{3,11})`, LeadingDigits: "3[02]|40|[68]9", Format: "0$1 $2", IntlFormat: "$1 $2"},
+			{Pattern: `(\d{3})(\d{3,11})`, Format: "0$1 $2", IntlFormat: "$1 $2"},
+		},
+	},
+	"IT": {
+		CountryCode: "IT",
+		DialCode:    "39",
+		// Italian area codes keep their leading 0, Milan and Rome are 02 and 06
+		AreaCodeLengths: map[string]int{
+			"": 3, "0": 4, "02": 2, "06": 2,
+			"010": 3, "011": 3, "015": 3, "019": 3, "030": 3, "031": 3, "035": 3, "039": 3,
+			"040": 3, "041": 3, "045": 3, "049": 3, "050": 3, "051": 3, "055": 3, "059": 3,
+			"070": 3, "071": 3, "075": 3, "079": 3, "080": 3, "081": 3, "085": 3, "089": 3,
+			"090": 3, "091": 3, "095": 3, "099": 3,
+		},
+		PossibleLengths:       []int{6, 7, 8, 9, 10, 11},
+		NationalNumberPattern: `0\d{5,10}|1\d{8,9}|3\d{8,10}|[58]\d{5,9}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `0\d{5,10}`,
+			Mobile:         `3[1-9]\d{7,8}`,
+			TollFree:       `80[03]\d{6}`,
+			PremiumRate:    `89[2-9]\d{3,6}`,
+			SharedCost:     `84\d{4,7}`,
+			PersonalNumber: `178\d{6,7}`,
+			Voip:           `55\d{8}`,
+			Voicemail:      `33\d{9}`,
+		},
+		InternationalPrefix: "00",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{2})(\d{4})(\d{4})`, LeadingDigits: "0[26]", Format: "$1 $2 $3"},
+			{Pattern: `(\d{3})(\d{3})(\d{4})`, LeadingDigits: "0[13-57-9][0159]|3", Format: "$1 $2 $3"},
+			{Pattern: `(\d{4})(\d{2,6})`, LeadingDigits: "0", Format: "$1 $2"},
+		},
+	},
+	"JP": {
+		CountryCode: "JP",
+		DialCode:    "81",
+		// Tokyo and Osaka have 1 digit, other large cities and mobiles 2 and the rest mostly 3
+		AreaCodeLengths: map[string]int{
+			"": 3, "3": 1, "6": 1, "20": 2, "50": 2, "70": 2, "80": 2, "90": 2,
+			"11": 2, "22": 2, "25": 2, "27": 2, "28": 2, "29": 2, "42": 2, "43": 2,
+			"44": 2, "45": 2, "46": 2, "47": 2, "48": 2, "52": 2, "53": 2, "54": 2,
+			"55": 2, "58": 2, "59": 2, "72": 2, "73": 2, "75": 2, "76": 2, "77": 2,
+			"78": 2, "82": 2, "83": 2, "84": 2, "86": 2, "87": 2, "88": 2, "89": 2,
+			"92": 2, "93": 2, "95": 2, "96": 2, "97": 2, "98": 2, "99": 2,
+		},
+		PossibleLengths:       []int{9, 10},
+		NationalNumberPattern: `[1-9]\d{8}|[25789]0\d{8}|800\d{7}`,
+		NumberTypes: map[NumberType]string{
+			FixedLine:      `[1-9]\d{8}`,
+			Mobile:         `[7-9]0[1-9]\d{7}`,
+			Pager:          `20\d{8}`,
+			TollFree:       `120\d{6}|800\d{7}`,
+			PremiumRate:    `990\d{6}`,
+			PersonalNumber: `60\d{7}`,
+			Voip:           `50[1-9]\d{7}`,
+			Uan:            `570\d{6}`,
+		},
+		TrunkPrefix:         "0",
+		InternationalPrefix: "010",
+		Formats: []NumberFormat{
+			{Pattern: `(\d{2})(\d{4})(\d{4})`, LeadingDigits: "[5789]0", Format: "0$1-$2-$3", IntlFormat: "$1-$2-$3"},
+			{Pattern: `(\d)(\d{4})(\d{4})`, LeadingDigits: "[36]", Format: "0$1-$2-$3", IntlFormat: "$1-$2-$3"},
+			{Pattern: `(\d{3})(\d{2})(\d{4})`, Format: "0$1-$2-$3", IntlFormat: "$1-$2-$3"},
+		},
+	},
+}
+
+// dialCodeTrie maps every dial code to the regions using it, built from RegionMetadataMap
+var dialCodeTrie *prefixTrie[[]string]
+
+func init() {
+	for countryCode, region := range RegionMetadataMap {
+		if err := region.prepare(); err != nil {
+			panic(fmt.Sprintf("invalid built-in metadata for %s: %v", countryCode, err))
+		}
+	}
+	installRegionMetadata(RegionMetadataMap)
+}
+
+// Swaps in a new set of region metadata, i.e. one loaded from libphonenumber at startup
+// The regions need to have been prepared already
+func installRegionMetadata(regions map[string]*RegionMetadata) {
+	RegionMetadataMap = regions
+	dialCodeTrie = buildDialCodeTrie(regions)
+}
+
+// Builds the dial code trie. Regions sharing a dial code are ordered with the main country first
+func buildDialCodeTrie(regions map[string]*RegionMetadata) *prefixTrie[[]string] {
+	regionsByDialCode := make(map[string][]string)
+	for countryCode, region := range regions {
+		regionsByDialCode[region.DialCode] = append(regionsByDialCode[region.DialCode], countryCode)
+	}
+
+	trie := &prefixTrie[[]string]{}
+	for dialCode, countryCodes := range regionsByDialCode {
+		sort.Slice(countryCodes, func(i, j int) bool {
+			mainI, mainJ := regions[countryCodes[i]].MainCountryForCode, regions[countryCodes[j]].MainCountryForCode
+			if mainI != mainJ {
+				return mainI
+			}
+			return countryCodes[i] < countryCodes[j]
+		})
+		trie.insert(dialCode, countryCodes)
+	}
+	return trie
+}
+
+// Looks up the metadata for a country code, case insensitive
+func lookupRegion(countryCode string) (*RegionMetadata, bool) {
+	region, exists := RegionMetadataMap[strings.ToUpper(countryCode)]
+	return region, exists
+}
+
+// Builds the lookup structures for the region. It has to be called before the region is used
+func (region *RegionMetadata) prepare() error {
+	region.areaCodeTrie = &prefixTrie[int]{}
+	for prefix, length := range region.AreaCodeLengths {
+		region.areaCodeTrie.insert(prefix, length)
+	}
+
+	if region.InternationalPrefix != "" {
+		internationalPrefix, err := regexp.Compile("^(?:" + region.InternationalPrefix + ")")
+		if err != nil {
+			return fmt.Errorf("invalid international prefix %q: %w", region.InternationalPrefix, err)
+		}
+		region.internationalPrefix = internationalPrefix
+	}
+
+	if region.NationalNumberPattern != "" {
+		nationalPattern, err := regexp.Compile("^(?:" + region.NationalNumberPattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid national number pattern %q: %w", region.NationalNumberPattern, err)
+		}
+		region.nationalPattern = nationalPattern
+	}
+
+	region.numberTypePatterns = make(map[NumberType]*regexp.Regexp)
+	for numberType, pattern := range region.NumberTypes {
+		compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid %s pattern %q: %w", numberType, pattern, err)
+		}
+		region.numberTypePatterns[numberType] = compiled
+	}
+
+	for i := range region.Formats {
+		format := &region.Formats[i]
+		pattern, err := regexp.Compile("^(?:" + format.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid format pattern %q: %w", format.Pattern, err)
+		}
+		format.pattern = pattern
+
+		if format.LeadingDigits != "" {
+			leadingDigits, err := regexp.Compile("^(?:" + format.LeadingDigits + ")")
+			if err != nil {
+				return fmt.Errorf("invalid leading digits %q: %w", format.LeadingDigits, err)
+			}
+			format.leadingDigits = leadingDigits
+		}
+	}
+
+	return nil
+}
+
+// Finds the first format that applies to the national number, nil if none do
+func (region *RegionMetadata) formatFor(nationalNumber string) *NumberFormat {
+	for i := range region.Formats {
+		format := &region.Formats[i]
+		if format.leadingDigits != nil && !format.leadingDigits.MatchString(nationalNumber) {
+			continue
+		}
+		if format.pattern != nil && format.pattern.MatchString(nationalNumber) {
+			return format
+		}
+	}
+	return nil
+}
+
+// Works out how long the area code at the start of the national number is.
+// The longest prefix in AreaCodeLengths that matches wins, with the empty prefix as the default for the country.
+// Countries without a table (i.e. only in libphonenumber) use the first group of their national format
+// as long as it has at least three groups, otherwise we say they have no area code
+func (region *RegionMetadata) areaCodeLength(nationalNumber string) int {
+	if region.areaCodeTrie != nil {
+		if _, length, found := region.areaCodeTrie.longestPrefix(nationalNumber); found {
+			return length
+		}
+	}
+
+	format := region.formatFor(nationalNumber)
+	if format == nil {
+		return 0
+	}
+	groups := format.pattern.FindStringSubmatch(nationalNumber)
+	if len(groups) < 4 {
+		return 0
+	}
+	return len(groups[1])
+}
+
+// Checks the length of a national number against the lengths the region allows
+func (region *RegionMetadata) isPossibleLength(nationalNumber string) bool {
+	for _, length := range region.PossibleLengths {
+		if len(nationalNumber) == length {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes the trunk prefix people dial in front of national numbers i.e. the 0 in 020 7946 0018.
+// We only strip it when what's left still looks like a number for the region, otherwise the digit is part of the number itself
+func (region *RegionMetadata) stripTrunkPrefix(nationalNumber string) string {
+	if region.TrunkPrefix == "" || !strings.HasPrefix(nationalNumber, region.TrunkPrefix) {
+		return nationalNumber
+	}
+
+	stripped := nationalNumber[len(region.TrunkPrefix):]
+	if !region.isPossibleLength(stripped) {
+		return nationalNumber
+	}
+	if region.nationalPattern != nil && !region.nationalPattern.MatchString(stripped) {
+		return nationalNumber
+	}
+	return stripped
+}
+
+// Checks the national number is valid for the region, returning why it isn't or "" if it is
+func (region *RegionMetadata) validateNationalNumber(nationalNumber string) string {
+	if len(region.PossibleLengths) > 0 {
+		shortest, longest := region.PossibleLengths[0], region.PossibleLengths[len(region.PossibleLengths)-1]
+		if len(nationalNumber) < shortest {
+			return "too short"
+		}
+		if len(nationalNumber) > longest {
+			return "too long"
+		}
+		if !region.isPossibleLength(nationalNumber) {
+			return "invalid for region"
+		}
+	}
+
+	if region.nationalPattern != nil && !region.nationalPattern.MatchString(nationalNumber) {
+		return "invalid for region"
+	}
+
+	return ""
+}
+
+// Splits a national number (everything after the dial code) into area code and local number
+func (region *RegionMetadata) splitAreaCode(nationalNumber string) (string, string) {
+	length := region.areaCodeLength(nationalNumber)
+	if length > 0 && len(nationalNumber) > length {
+		return nationalNumber[:length], nationalNumber[length:]
+	}
+	return "", nationalNumber
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLookupRegion(t *testing.T) {
+	tests := []struct {
+		name             string
+		countryCode      string
+		expectedDialCode string
+		expectedFound    bool
+	}{
+		{"US", "US", "1", true},
+		{"Lowercase mx", "mx", "52", true},
+		{"Portugal", "PT", "351", true},
+		{"Unsupported", "XX", "", false},
+		{"Empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, found := lookupRegion(tt.countryCode)
+			if found != tt.expectedFound {
+				t.Fatalf("lookupRegion(%s) found = %v, expected %v", tt.countryCode, found, tt.expectedFound)
+			}
+			if found && region.DialCode != tt.expectedDialCode {
+				t.Errorf("lookupRegion(%s) dial code = %s, expected %s", tt.countryCode, region.DialCode, tt.expectedDialCode)
+			}
+		})
+	}
+}
+
+func TestSplitAreaCode(t *testing.T) {
+	tests := []struct {
+		name           string
+		countryCode    string
+		nationalNumber string
+		expectedArea   string
+		expectedLocal  string
+	}{
+		{"US", "US", "2125690123", "212", "5690123"},
+		{"Portugal", "PT", "210942000", "21", "0942000"},
+		{"France", "FR", "612345678", "6", "12345678"},
+		{"Portugal outside Lisbon", "PT", "253123456", "253", "123456"},
+		{"Mexico City", "MX", "5512345678", "55", "12345678"},
+		{"London", "GB", "2079460018", "20", "79460018"},
+		{"Birmingham", "GB", "1216060000", "121", "6060000"},
+		{"Drama number", "GB", "1632960001", "1632", "960001"},
+		{"Sedbergh", "GB", "1539612345", "15396", "12345"},
+		{"Berlin", "DE", "301234567", "30", "1234567"},
+		{"Munich", "DE", "8912345678", "89", "12345678"},
+		{"Heidelberg", "DE", "6221123456", "6221", "123456"},
+		{"Mannheim", "DE", "6211234567", "621", "1234567"},
+		{"German mobile", "DE", "15112345678", "151", "12345678"},
+		{"Milan", "IT", "0212345678", "02", "12345678"},
+		{"Turin", "IT", "0111234567", "011", "1234567"},
+		{"Italian small town", "IT", "0331123456", "0331", "123456"},
+		{"Italian mobile", "IT", "3123456789", "312", "3456789"},
+		{"Tokyo", "JP", "312345678", "3", "12345678"},
+		{"Yokohama", "JP", "451234567", "45", "1234567"},
+		{"Japanese mobile", "JP", "9012345678", "90", "12345678"},
+		{"Japanese small city", "JP", "268123456", "268", "123456"},
+		{"Too short for an area code", "US", "212", "", "212"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, _ := lookupRegion(tt.countryCode)
+			areaCode, localNumber := region.splitAreaCode(tt.nationalNumber)
+			if areaCode != tt.expectedArea || localNumber != tt.expectedLocal {
+				t.Errorf("splitAreaCode(%s) = (%s, %s), expected (%s, %s)",
+					tt.nationalNumber, areaCode, localNumber, tt.expectedArea, tt.expectedLocal)
+			}
+		})
+	}
+}
+
+func TestStripTrunkPrefix(t *testing.T) {
+	tests := []struct {
+		name           string
+		countryCode    string
+		nationalNumber string
+		expected       string
+	}{
+		{"UK with trunk prefix", "GB", "02079460018", "2079460018"},
+		{"UK without trunk prefix", "GB", "2079460018", "2079460018"},
+		{"France mobile", "FR", "0612345678", "612345678"},
+		{"Germany", "DE", "0301234567", "301234567"},
+		{"Japan", "JP", "0312345678", "312345678"},
+		{"NANP long distance", "US", "12125690123", "2125690123"},
+		{"NANP leading 1 without room for it", "US", "1212569012", "1212569012"},
+		{"Italy keeps its leading 0", "IT", "0212345678", "0212345678"},
+		{"Spain has no trunk prefix", "ES", "915872200", "915872200"},
+		{"Trunk prefix leaves an impossible length", "FR", "061234567", "061234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, _ := lookupRegion(tt.countryCode)
+			result := region.stripTrunkPrefix(tt.nationalNumber)
+			if result != tt.expected {
+				t.Errorf("stripTrunkPrefix(%s) = %s, expected %s", tt.nationalNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateNationalNumber(t *testing.T) {
+	tests := []struct {
+		name           string
+		countryCode    string
+		nationalNumber string
+		expected       string
+	}{
+		{"Valid US", "US", "2125690123", ""},
+		{"US too short", "US", "212", "too short"},
+		{"US too long", "US", "21256901234", "too long"},
+		{"US exchange starting with 0", "US", "2120690123", "invalid for region"},
+		{"US area code starting with 1", "US", "1125690123", "invalid for region"},
+		{"Valid UK", "GB", "2079460018", ""},
+		{"UK too long", "GB", "123456789012345", "too long"},
+		{"UK 4 prefix isn't used", "GB", "4079460018", "invalid for region"},
+		{"Valid Germany short number", "DE", "62211234", ""},
+		{"Italy too long", "IT", "021234567890", "too long"},
+		{"Valid Japan mobile", "JP", "9012345678", ""},
+		{"Japan 10 digit fixed line", "JP", "3123456789", "invalid for region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, _ := lookupRegion(tt.countryCode)
+			result := region.validateNationalNumber(tt.nationalNumber)
+			if result != tt.expected {
+				t.Errorf("validateNationalNumber(%s) = %q, expected %q", tt.nationalNumber, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Structure of libphonenumber's PhoneNumberMetadata.xml, only the parts we use
+// See: https://github.com/google/libphonenumber/blob/master/resources/PhoneNumberMetadata.xml
+type xmlPhoneNumberMetadata struct {
+	Territories []xmlTerritory `xml:"territories>territory"`
+}
+
+type xmlTerritory struct {
+	ID                           string            `xml:"id,attr"`
+	CountryCode                  string            `xml:"countryCode,attr"`
+	InternationalPrefix          string            `xml:"internationalPrefix,attr"`
+	NationalPrefix               string            `xml:"nationalPrefix,attr"`
+	NationalPrefixFormattingRule string            `xml:"nationalPrefixFormattingRule,attr"`
+	MainCountryForCode           bool              `xml:"mainCountryForCode,attr"`
+	AvailableFormats             []xmlNumberFormat `xml:"availableFormats>numberFormat"`
+	GeneralDesc                  xmlNumberDesc     `xml:"generalDesc"`
+	FixedLine                    *xmlNumberDesc    `xml:"fixedLine"`
+	Mobile                       *xmlNumberDesc    `xml:"mobile"`
+	Pager                        *xmlNumberDesc    `xml:"pager"`
+	TollFree                     *xmlNumberDesc    `xml:"tollFree"`
+	PremiumRate                  *xmlNumberDesc    `xml:"premiumRate"`
+	SharedCost                   *xmlNumberDesc    `xml:"sharedCost"`
+	PersonalNumber               *xmlNumberDesc    `xml:"personalNumber"`
+	Voip                         *xmlNumberDesc    `xml:"voip"`
+	Uan                          *xmlNumberDesc    `xml:"uan"`
+	Voicemail                    *xmlNumberDesc    `xml:"voicemail"`
+}
+
+type xmlNumberFormat struct {
+	Pattern                      string   `xml:"pattern,attr"`
+	NationalPrefixFormattingRule string   `xml:"nationalPrefixFormattingRule,attr"`
+	LeadingDigits                []string `xml:"leadingDigits"`
+	Format                       string   `xml:"format"`
+	IntlFormat                   string   `xml:"intlFormat"`
+}
+
+type xmlNumberDesc struct {
+	NationalNumberPattern string `xml:"nationalNumberPattern"`
+	PossibleLengths       struct {
+		National string `xml:"national,attr"`
+	} `xml:"possibleLengths"`
+}
+
+// Loads region metadata from a PhoneNumberMetadata.xml file on disk
+func loadRegionMetadataFile(path string) (map[string]*RegionMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseRegionMetadataXML(file)
+}
+
+// Builds our region tables out of libphonenumber's metadata
+func parseRegionMetadataXML(reader io.Reader) (map[string]*RegionMetadata, error) {
+	var metadata xmlPhoneNumberMetadata
+	if err := xml.NewDecoder(reader).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("could not decode metadata: %w", err)
+	}
+
+	regions := make(map[string]*RegionMetadata)
+	for _, territory := range metadata.Territories {
+		// 001 is used for non-geographic entities (i.e. +800) which don't have an ISO country code
+		if !validateCountryCodeMeetsISO_3166_1_alpha_2(territory.ID) {
+			continue
+		}
+
+		region, err := territory.toRegionMetadata()
+		if err != nil {
+			return nil, fmt.Errorf("territory %s: %w", territory.ID, err)
+		}
+
+		// libphonenumber doesn't know about area codes so keep the tables we already had for this country
+		if builtin, exists := RegionMetadataMap[region.CountryCode]; exists {
+			region.AreaCodeLengths = builtin.AreaCodeLengths
+		}
+
+		if err := region.prepare(); err != nil {
+			return nil, fmt.Errorf("territory %s: %w", territory.ID, err)
+		}
+
+		regions[region.CountryCode] = region
+	}
+
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no territories found in metadata")
+	}
+
+	return regions, nil
+}
+
+func (territory xmlTerritory) toRegionMetadata() (*RegionMetadata, error) {
+	if territory.CountryCode == "" {
+		return nil, fmt.Errorf("missing countryCode")
+	}
+	if !regexp.MustCompile(`^[0-9]+$`).MatchString(territory.CountryCode) {
+		return nil, fmt.Errorf("invalid countryCode %q", territory.CountryCode)
+	}
+
+	region := &RegionMetadata{
+		CountryCode:           strings.ToUpper(territory.ID),
+		DialCode:              territory.CountryCode,
+		TrunkPrefix:           territory.NationalPrefix,
+		InternationalPrefix:   stripWhitespace(territory.InternationalPrefix),
+		NationalNumberPattern: stripWhitespace(territory.GeneralDesc.NationalNumberPattern),
+		MainCountryForCode:    territory.MainCountryForCode,
+	}
+
+	// The possible lengths live on the individual number types, the region accepts any of them
+	lengths := make(map[int]bool)
+	region.NumberTypes = make(map[NumberType]string)
+	for numberType, desc := range territory.numberDescs() {
+		parsed, err := parsePossibleLengths(desc.PossibleLengths.National)
+		if err != nil {
+			return nil, err
+		}
+		for _, length := range parsed {
+			lengths[length] = true
+		}
+
+		if pattern := stripWhitespace(desc.NationalNumberPattern); pattern != "" {
+			region.NumberTypes[numberType] = pattern
+		}
+	}
+	for length := 1; length <= 17; length++ {
+		if lengths[length] {
+			region.PossibleLengths = append(region.PossibleLengths, length)
+		}
+	}
+
+	for _, format := range territory.AvailableFormats {
+		region.Formats = append(region.Formats, territory.toNumberFormat(format))
+	}
+
+	return region, nil
+}
+
+// All the number type descriptions present on the territory
+func (territory xmlTerritory) numberDescs() map[NumberType]*xmlNumberDesc {
+	descs := make(map[NumberType]*xmlNumberDesc)
+	for numberType, desc := range map[NumberType]*xmlNumberDesc{
+		FixedLine:      territory.FixedLine,
+		Mobile:         territory.Mobile,
+		Pager:          territory.Pager,
+		TollFree:       territory.TollFree,
+		PremiumRate:    territory.PremiumRate,
+		SharedCost:     territory.SharedCost,
+		PersonalNumber: territory.PersonalNumber,
+		Voip:           territory.Voip,
+		Uan:            territory.Uan,
+		Voicemail:      territory.Voicemail,
+	} {
+		if desc != nil {
+			descs[numberType] = desc
+		}
+	}
+	return descs
+}
+
+func (territory xmlTerritory) toNumberFormat(format xmlNumberFormat) NumberFormat {
+	numberFormat := NumberFormat{
+		Pattern:    stripWhitespace(format.Pattern),
+		Format:     format.Format,
+		IntlFormat: format.IntlFormat,
+	}
+
+	// The last leading digits pattern is the most specific one
+	if len(format.LeadingDigits) > 0 {
+		numberFormat.LeadingDigits = stripWhitespace(format.LeadingDigits[len(format.LeadingDigits)-1])
+	}
+
+	// libphonenumber keeps the trunk prefix in a separate rule (i.e. "$NP$FG") so we bake it into the national format
+	rule := format.NationalPrefixFormattingRule
+	if rule == "" {
+		rule = territory.NationalPrefixFormattingRule
+	}
+	if rule != "" && strings.Contains(format.Format, "$1") {
+		if numberFormat.IntlFormat == "" {
+			numberFormat.IntlFormat = format.Format
+		}
+		rule = strings.ReplaceAll(rule, "$NP", territory.NationalPrefix)
+		rule = strings.ReplaceAll(rule, "$FG", "$1")
+		numberFormat.Format = strings.Replace(format.Format, "$1", rule, 1)
+	}
+
+	return numberFormat
+}
+
+// Parses libphonenumber's possible length notation i.e. "7,[9-11],13"
+func parsePossibleLengths(lengths string) ([]int, error) {
+	var result []int
+	if lengths == "" || lengths == "-1" {
+		return result, nil
+	}
+
+	for _, part := range strings.Split(lengths, ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			bounds := strings.Split(strings.Trim(part, "[]"), "-")
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("invalid possible length range %q", part)
+			}
+			low, lowErr := strconv.Atoi(bounds[0])
+			high, highErr := strconv.Atoi(bounds[1])
+			if lowErr != nil || highErr != nil || low > high {
+				return nil, fmt.Errorf("invalid possible length range %q", part)
+			}
+			for length := low; length <= high; length++ {
+				result = append(result, length)
+			}
+			continue
+		}
+
+		length, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid possible length %q", part)
+		}
+		result = append(result, length)
+	}
+
+	return result, nil
+}
+
+// Patterns in the XML are split over several indented lines
+func stripWhitespace(pattern string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, pattern)
+}
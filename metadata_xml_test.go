@@ -0,0 +1,159 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadRegionMetadataFile(t *testing.T) {
+	regions, err := loadRegionMetadataFile("testdata/PhoneNumberMetadata.xml")
+	if err != nil {
+		t.Fatalf("Could not load metadata: %v", err)
+	}
+
+	if _, exists := regions["001"]; exists {
+		t.Errorf("Expected non-geographic entity 001 to be skipped")
+	}
+	if len(regions) != 3 {
+		t.Errorf("Expected 3 regions, got %d", len(regions))
+	}
+
+	us := regions["US"]
+	if us.DialCode != "1" || us.TrunkPrefix != "1" || us.InternationalPrefix != "011" {
+		t.Errorf("Unexpected US metadata %+v", us)
+	}
+	if us.AreaCodeLengths[""] != 3 {
+		t.Errorf("Expected US to keep its area code table, got %v", us.AreaCodeLengths)
+	}
+	if !reflect.DeepEqual(us.PossibleLengths, []int{10}) {
+		t.Errorf("Expected US possible lengths [10], got %v", us.PossibleLengths)
+	}
+	if us.Formats[1].Format != "($1) $2-$3" || us.Formats[1].IntlFormat != "$1-$2-$3" {
+		t.Errorf("Expected national prefix formatting rule to be applied, got %+v", us.Formats[1])
+	}
+
+	if numberType := us.numberType("2015550123"); numberType != FixedLineOrMobile {
+		t.Errorf("Expected US number to be %s, got %s", FixedLineOrMobile, numberType)
+	}
+	if numberType := us.numberType("8002345678"); numberType != TollFree {
+		t.Errorf("Expected US number to be %s, got %s", TollFree, numberType)
+	}
+
+	if formats := us.formatNumber("2015550123"); formats.National != "(201) 555-0123" || formats.International != "+1 201-555-0123" {
+		t.Errorf("Unexpected US formats %+v", formats)
+	}
+
+	gb := regions["GB"]
+	if !reflect.DeepEqual(gb.PossibleLengths, []int{7, 9, 10}) {
+		t.Errorf("Expected GB possible lengths [7 9 10], got %v", gb.PossibleLengths)
+	}
+	if gb.NationalNumberPattern != `[1-357-9]\d{9}|[18]\d{8}|8\d{6}` {
+		t.Errorf("Expected whitespace to be stripped from pattern, got %s", gb.NationalNumberPattern)
+	}
+	if gb.Formats[0].Format != "0$1 $2 $3" || gb.Formats[0].IntlFormat != "$1 $2 $3" {
+		t.Errorf("Unexpected GB format %+v", gb.Formats[0])
+	}
+	if gb.Formats[1].LeadingDigits != "7(?:[1-57-9]|62)" {
+		t.Errorf("Expected the most specific leading digits, got %s", gb.Formats[1].LeadingDigits)
+	}
+
+	if numberType := gb.numberType("7400123456"); numberType != Mobile {
+		t.Errorf("Expected GB number to be %s, got %s", Mobile, numberType)
+	}
+
+	nz := regions["NZ"]
+	if nz.DialCode != "64" || nz.InternationalPrefix != "0(?:0|161)" {
+		t.Errorf("Unexpected NZ metadata %+v", nz)
+	}
+	if !reflect.DeepEqual(nz.PossibleLengths, []int{8, 9, 10}) {
+		t.Errorf("Expected NZ possible lengths [8 9 10], got %v", nz.PossibleLengths)
+	}
+
+	// NZ has no area code table so the split comes from its national format
+	areaCode, localNumber := nz.splitAreaCode("91234567")
+	if areaCode != "9" || localNumber != "1234567" {
+		t.Errorf("Expected NZ area code from format, got (%s, %s)", areaCode, localNumber)
+	}
+	areaCode, localNumber = nz.splitAreaCode("211234567")
+	if areaCode != "" || localNumber != "211234567" {
+		t.Errorf("Expected no area code without a matching format, got (%s, %s)", areaCode, localNumber)
+	}
+}
+
+func TestLoadRegionMetadataFileErrors(t *testing.T) {
+	if _, err := loadRegionMetadataFile("testdata/missing.xml"); err == nil {
+		t.Errorf("Expected error for missing file")
+	}
+
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{"Not XML", "this is not xml"},
+		{"No territories", "<phoneNumberMetadata><territories></territories></phoneNumberMetadata>"},
+		{"Missing country code", `<phoneNumberMetadata><territories><territory id="US"/></territories></phoneNumberMetadata>`},
+		{"Bad possible lengths", `<phoneNumberMetadata><territories><territory id="US" countryCode="1">
+			<fixedLine><possibleLengths national="[10]"/></fixedLine></territory></territories></phoneNumberMetadata>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRegionMetadataXML(strings.NewReader(tt.xml)); err == nil {
+				t.Errorf("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestParsePossibleLengths(t *testing.T) {
+	tests := []struct {
+		name        string
+		lengths     string
+		expected    []int
+		expectError bool
+	}{
+		{"Single", "10", []int{10}, false},
+		{"List", "7,9,10", []int{7, 9, 10}, false},
+		{"Range", "[8-10]", []int{8, 9, 10}, false},
+		{"Mixed", "6,[8-9],12", []int{6, 8, 9, 12}, false},
+		{"Not applicable", "-1", nil, false},
+		{"Empty", "", nil, false},
+		{"Bad range", "[10-8]", nil, true},
+		{"Not a number", "ten", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parsePossibleLengths(tt.lengths)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parsePossibleLengths(%s) error = %v, expectError %v", tt.lengths, err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parsePossibleLengths(%s) = %v, expected %v", tt.lengths, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstallRegionMetadata(t *testing.T) {
+	regions, err := loadRegionMetadataFile("testdata/PhoneNumberMetadata.xml")
+	if err != nil {
+		t.Fatalf("Could not load metadata: %v", err)
+	}
+
+	builtin := RegionMetadataMap
+	installRegionMetadata(regions)
+	defer installRegionMetadata(builtin)
+
+	// NZ isn't one of the built-in countries so it has to come from the loaded metadata
+	country, dialCode, found := extractCountryCodeFromNumber("+6491234567")
+	if country != "NZ" || dialCode != "64" || !found {
+		t.Errorf("Expected NZ from loaded metadata, got (%s, %s, %v)", country, dialCode, found)
+	}
+
+	// And the built-in countries that aren't in the file are gone
+	if _, _, found := extractCountryCodeFromNumber("+34915872200"); found {
+		t.Errorf("Expected ES to be unknown after installing metadata")
+	}
+}
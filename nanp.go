@@ -0,0 +1,76 @@
+package main
+
+// nanpAreaCodes maps the area codes (NPAs) of the North American Numbering Plan outside the US to their country
+// Anything not in here is treated as US, which covers the US states and non-geographic codes like 800
+// See: https://nationalnanpa.com/enas/geoAreaCodeNumberReport.do
+var nanpAreaCodes = map[string]string{
+	// Canada
+	"204": "CA", "226": "CA", "236": "CA", "249": "CA", "250": "CA", "257": "CA", "263": "CA", "289": "CA",
+	"306": "CA", "343": "CA", "354": "CA", "365": "CA", "367": "CA", "368": "CA", "382": "CA", "403": "CA",
+	"416": "CA", "418": "CA", "428": "CA", "431": "CA", "437": "CA", "438": "CA", "450": "CA", "460": "CA",
+	"468": "CA", "474": "CA", "506": "CA", "514": "CA", "519": "CA", "548": "CA", "579": "CA", "581": "CA",
+	"584": "CA", "587": "CA", "604": "CA", "613": "CA", "639": "CA", "647": "CA", "672": "CA", "683": "CA",
+	"705": "CA", "709": "CA", "742": "CA", "753": "CA", "778": "CA", "780": "CA", "782": "CA", "807": "CA",
+	"819": "CA", "825": "CA", "867": "CA", "873": "CA", "879": "CA", "902": "CA", "905": "CA", "942": "CA",
+
+	// Caribbean and Atlantic
+	"242": "BS", // Bahamas
+	"246": "BB", // Barbados
+	"264": "AI", // Anguilla
+	"268": "AG", // Antigua and Barbuda
+	"284": "VG", // British Virgin Islands
+	"345": "KY", // Cayman Islands
+	"441": "BM", // Bermuda
+	"473": "GD", // Grenada
+	"649": "TC", // Turks and Caicos Islands
+	"658": "JM", // Jamaica
+	"664": "MS", // Montserrat
+	"721": "SX", // Sint Maarten
+	"758": "LC", // Saint Lucia
+	"767": "DM", // Dominica
+	"784": "VC", // Saint Vincent and the Grenadines
+	"809": "DO", // Dominican Republic
+	"829": "DO",
+	"849": "DO",
+	"868": "TT", // Trinidad and Tobago
+	"869": "KN", // Saint Kitts and Nevis
+	"876": "JM",
+
+	// US territories with their own ISO country code
+	"340": "VI", // US Virgin Islands
+	"670": "MP", // Northern Mariana Islands
+	"671": "GU", // Guam
+	"684": "AS", // American Samoa
+	"787": "PR", // Puerto Rico
+	"939": "PR",
+}
+
+// Creates the metadata for a NANP country other than the US. They all share the same numbering rules
+func nanpRegion(countryCode string) *RegionMetadata {
+	return &RegionMetadata{
+		CountryCode:           countryCode,
+		DialCode:              "1",
+		AreaCodeLengths:       map[string]int{"": 3},
+		PossibleLengths:       []int{10},
+		NationalNumberPattern: nanpPattern,
+		NumberTypes:           nanpNumberTypes,
+		TrunkPrefix:           "1",
+		InternationalPrefix:   "011",
+		Formats:               nanpFormats,
+	}
+}
+
+// Picks the country for a +1 number out of the regions we know about using its area code
+// Falls back to the main country (US) for unassigned area codes
+func resolveNANPCountry(nationalNumber string, countryCodes []string) string {
+	if len(nationalNumber) >= 3 {
+		if country, exists := nanpAreaCodes[nationalNumber[:3]]; exists {
+			for _, countryCode := range countryCodes {
+				if countryCode == country {
+					return country
+				}
+			}
+		}
+	}
+	return countryCodes[0]
+}
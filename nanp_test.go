@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestResolveNANPCountry(t *testing.T) {
+	allRegions := []string{"US", "CA", "JM", "PR"}
+
+	tests := []struct {
+		name           string
+		nationalNumber string
+		countryCodes   []string
+		expected       string
+	}{
+		{"Manhattan", "2125690123", allRegions, "US"},
+		{"Toronto", "4165550123", allRegions, "CA"},
+		{"Jamaica", "8765550123", allRegions, "JM"},
+		{"Puerto Rico", "9395550123", allRegions, "PR"},
+		{"Unassigned area code", "5555550123", allRegions, "US"},
+		{"Country we have no metadata for", "2465550123", allRegions, "US"},
+		{"Too short for an area code", "41", allRegions, "US"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := resolveNANPCountry(tt.nationalNumber, tt.countryCodes)
+			if result != tt.expected {
+				t.Errorf("resolveNANPCountry(%s) = %s, expected %s", tt.nationalNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNANPAreaCodesHaveMetadata(t *testing.T) {
+	for areaCode, countryCode := range nanpAreaCodes {
+		region, exists := lookupRegion(countryCode)
+		if !exists {
+			t.Errorf("Area code %s maps to %s which has no metadata", areaCode, countryCode)
+			continue
+		}
+		if region.DialCode != "1" {
+			t.Errorf("Area code %s maps to %s which has dial code %s", areaCode, countryCode, region.DialCode)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "strings"
+
+// The zero of each digit set we turn into ASCII, the other nine digits follow it
+var digitZeros = []rune{
+	'٠', // Arabic-Indic
+	'۰', // Extended Arabic-Indic, used in Persian and Urdu
+	'०', // Devanagari
+}
+
+// Turns the characters people paste in from non-Latin interfaces into their ASCII equivalents:
+// full-width forms (１２３, ＋), other digit sets (١٢٣) and the various non-breaking spaces.
+// Invisible direction marks that come along when copying from right to left text are removed
+func normalizeUnicode(phoneNumber string) string {
+	return strings.Map(normalizeRune, phoneNumber)
+}
+
+// The ASCII equivalent of a single character, or -1 if it should be removed
+func normalizeRune(r rune) rune {
+	switch {
+	case r < 0x80:
+		return r
+	// Full-width forms mirror ASCII ! to ~ i.e. ０ to 0 and ＋ to +
+	case r >= '！' && r <= '～':
+		return r - 0xFEE0
+	// No-break, figure, narrow no-break and ideographic spaces
+	case r == '\u00a0', r == '\u2007', r == '\u202f', r == '\u3000':
+		return ' '
+	// Left to right, right to left and Arabic letter marks
+	case r == '\u200e', r == '\u200f', r == '\u061c':
+		return -1
+	}
+
+	for _, zero := range digitZeros {
+		if r >= zero && r <= zero+9 {
+			return '0' + r - zero
+		}
+	}
+	return r
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestNormalizeUnicode(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		expected    string
+	}{
+		{"ASCII is unchanged", "+1 212 569 0123", "+1 212 569 0123"},
+		{"Full-width digits and plus", "＋８１ ３ １２３４ ５６７８", "+81 3 1234 5678"},
+		{"Full-width punctuation", "（２１２）５６９－０１２３", "(212)569-0123"},
+		{"Arabic-Indic digits", "+٩٧١ ٤ ٣١٢٣٤٥٦", "+971 4 3123456"},
+		{"Extended Arabic-Indic digits", "+۹۸ ۲۱ ۱۲۳۴۵۶۷۸", "+98 21 12345678"},
+		{"Devanagari digits", "+९१ ११ २३४५६७८९", "+91 11 23456789"},
+		{"Non-breaking spaces", "+1\u00a0212\u202f5690123", "+1 212 5690123"},
+		{"Ideographic space", "+81\u30003 1234 5678", "+81 3 1234 5678"},
+		{"Direction marks are removed", "\u200e+1 212 5690123\u200f", "+1 212 5690123"},
+		{"Other characters are kept", "+1 212 569 0123 ☎", "+1 212 569 0123 ☎"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := normalizeUnicode(tt.phoneNumber); result != tt.expected {
+				t.Errorf("normalizeUnicode(%s) = %q, expected %q", tt.phoneNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePhoneNumberUnicode(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		countryCode string
+		expected    string
+	}{
+		{"Full-width", "＋８１ ３ １２３４５６７８", "", "+81312345678"},
+		{"Arabic-Indic", "+٣٤ ٩١٥ ٨٧٢٢٠٠", "", "+34915872200"},
+		{"Non-breaking space", "+1\u00a0212\u00a05690123", "", "+12125690123"},
+		{"National with full-width digits", "０３ １２３４ ５６７８", "JP", "+81312345678"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errorResp := parsePhoneNumber(tt.phoneNumber, tt.countryCode)
+			if errorResp != nil {
+				t.Fatalf("Expected no error but got %v", errorResp.Error)
+			}
+			if result.PhoneNumber != tt.expected {
+				t.Errorf("Expected phoneNumber %s, got %s", tt.expected, result.PhoneNumber)
+			}
+		})
+	}
+
+	// Errors still echo what we were sent
+	_, errorResp := parsePhoneNumber("＋８１ ３ ABC", "")
+	if errorResp == nil || errorResp.PhoneNumber != "＋８１ ３ ABC" {
+		t.Errorf("Expected error echoing the original input, got %v", errorResp)
+	}
+}
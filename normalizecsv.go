@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Columns added to the end of every row of a normalised CSV. They're prefixed so they can't be mixed up with the
+// file's own columns, i.e. a countryCode column used as the countryColumn
+var normalizedCSVColumns = []string{"normalized_e164", "normalized_countryCode", "normalized_areaCode", "normalized_localPhoneNumber", "normalized_error"}
+
+// Parses the number in numberColumn of every row, and the country in countryColumn if one is given, and returns the
+// CSV as it was with normalizedCSVColumns added to each row. The first row is the header. Like a batch, the file can
+// have at most maxBatchItems rows
+func normalizeCSV(reader io.Reader, numberColumn, countryColumn string, options ParseOptions) ([][]string, *ErrorResponse) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, errorResp := readCSVRecord(csvReader)
+	if errorResp != nil {
+		return nil, errorResp
+	}
+	if header == nil {
+		return nil, &ErrorResponse{
+			Error: map[string]string{"file": "missing header row"},
+		}
+	}
+
+	columns := csvColumns(header)
+	if _, exists := columns[numberColumn]; !exists {
+		return nil, &ErrorResponse{
+			Error: map[string]string{"numberColumn": "column not found"},
+		}
+	}
+	if _, exists := columns[countryColumn]; countryColumn != "" && !exists {
+		return nil, &ErrorResponse{
+			Error: map[string]string{"countryColumn": "column not found"},
+		}
+	}
+	for _, name := range normalizedCSVColumns {
+		if _, exists := columns[name]; exists {
+			return nil, &ErrorResponse{
+				Error: map[string]string{"file": "already has a " + name + " column"},
+			}
+		}
+	}
+
+	// Read a row at a time so a file that's too big is turned away without reading the rest of it
+	records := [][]string{header}
+	for {
+		record, errorResp := readCSVRecord(csvReader)
+		if errorResp != nil {
+			return nil, errorResp
+		}
+		if record == nil {
+			break
+		}
+		if len(records) > maxBatchItems {
+			return nil, &ErrorResponse{
+				Error: map[string]string{"file": fmt.Sprintf("too many rows, the maximum is %d", maxBatchItems)},
+			}
+		}
+		records = append(records, record)
+	}
+
+	items := make([]BatchItem, len(records)-1)
+	for i, record := range records[1:] {
+		items[i].PhoneNumber = csvField(record, columns, numberColumn)
+		if countryColumn != "" {
+			items[i].CountryCode = csvField(record, columns, countryColumn)
+		}
+	}
+	results := parseBatch(items, options)
+
+	output := make([][]string, len(records))
+	output[0] = slices.Concat(header, normalizedCSVColumns)
+	for i, record := range records[1:] {
+		// Short rows are padded so the new columns line up with the header
+		for len(record) < len(header) {
+			record = append(record, "")
+		}
+
+		switch result := results[i].(type) {
+		case *PhoneNumberResponse:
+			output[i+1] = append(record, result.PhoneNumber, result.CountryCode, result.AreaCode, result.LocalPhoneNumber, "")
+		case *ErrorResponse:
+			output[i+1] = append(record, "", "", "", "", formatCSVError(result))
+		}
+	}
+
+	return output, nil
+}
+
+// Reads the next record, nil at the end of the file
+func readCSVRecord(csvReader *csv.Reader) ([]string, *ErrorResponse) {
+	record, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &ErrorResponse{
+			Error: map[string]string{"file": fmt.Sprintf("invalid CSV on line %d", parseErr.StartLine)},
+		}
+	}
+	if err != nil {
+		return nil, &ErrorResponse{
+			Error: map[string]string{"file": "could not be read"},
+		}
+	}
+	return record, nil
+}
+
+// Fits an error response into one cell i.e. "countryCode: required value is missing"
+func formatCSVError(errorResp *ErrorResponse) string {
+	var messages []string
+	for field, message := range errorResp.Error {
+		messages = append(messages, field+": "+message)
+	}
+	sort.Strings(messages)
+	return strings.Join(messages, "; ")
+}
+
+// Names the download after the upload so it's easy to tell apart i.e. numbers.csv comes back as numbers-normalized.csv
+func normalizedCSVFilename(upload string) string {
+	name := strings.TrimSuffix(filepath.Base(upload), filepath.Ext(upload))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "phone-numbers"
+	}
+	return name + "-normalized.csv"
+}
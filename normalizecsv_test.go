@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCSV(t *testing.T) {
+	// Saved from a spreadsheet with a byte order mark, which stays in the output as it was
+	input := "\ufeffname,phone,country\n" +
+		"Alice,(212) 569-0123,US\n" +
+		"Bob,631 311 8150,MX\n" +
+		"Carol,631 311 8150,\n" +
+		"Dan,,US\n" +
+		"Erin,+34915872200\n"
+
+	records, errorResp := normalizeCSV(strings.NewReader(input), "phone", "country", ParseOptions{Mode: ModeLenient, CountryCodeConflict: ConflictWarn})
+	if errorResp != nil {
+		t.Fatalf("Expected no error, got %v", errorResp.Error)
+	}
+
+	expected := [][]string{
+		{"\ufeffname", "phone", "country", "normalized_e164", "normalized_countryCode", "normalized_areaCode", "normalized_localPhoneNumber", "normalized_error"},
+		{"Alice", "(212) 569-0123", "US", "+12125690123", "US", "212", "5690123", ""},
+		{"Bob", "631 311 8150", "MX", "+526313118150", "MX", "631", "3118150", ""},
+		{"Carol", "631 311 8150", "", "", "", "", "", "countryCode: required value is missing"},
+		{"Dan", "", "US", "", "", "", "", "phoneNumber: required value is missing"},
+		{"Erin", "+34915872200", "", "+34915872200", "ES", "915", "872200", ""},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(records[i], expected[i]) {
+			t.Errorf("Row %d: expected %q, got %q", i, expected[i], records[i])
+		}
+	}
+}
+
+func TestNormalizeCSVWithoutCountryColumn(t *testing.T) {
+	records, errorResp := normalizeCSV(strings.NewReader("phoneNumber\n+12125690123\n"), "phoneNumber", "", ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+	if errorResp != nil {
+		t.Fatalf("Expected no error, got %v", errorResp.Error)
+	}
+
+	expected := []string{"+12125690123", "+12125690123", "US", "212", "5690123", ""}
+	if len(records) != 2 || !reflect.DeepEqual(records[1], expected) {
+		t.Errorf("Expected %q, got %q", expected, records)
+	}
+}
+
+func TestNormalizeCSVErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		numberColumn  string
+		countryColumn string
+		errorKey      string
+		errorValue    string
+	}{
+		{"Empty file", "", "phone", "", "file", "missing header row"},
+		{"Unknown number column", "name,phone\n", "number", "", "numberColumn", "column not found"},
+		{"Unknown country column", "name,phone\n", "phone", "country", "countryColumn", "column not found"},
+		{"Added column already there", "phone,normalized_e164\n", "phone", "", "file", "already has a normalized_e164 column"},
+		{"Broken quotes", "phone\n\"+12125690123\n+34915872200\"x\n", "phone", "", "file", "invalid CSV on line 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errorResp := normalizeCSV(strings.NewReader(tt.input), tt.numberColumn, tt.countryColumn, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+			if errorResp == nil {
+				t.Fatalf("Expected error %s: %s, got none", tt.errorKey, tt.errorValue)
+			}
+			if errorResp.Error[tt.errorKey] != tt.errorValue {
+				t.Errorf("Expected error %s: %s, got %v", tt.errorKey, tt.errorValue, errorResp.Error)
+			}
+		})
+	}
+}
+
+func TestNormalizeCSVCountryCodeColumn(t *testing.T) {
+	// A countryCode column of its own doesn't clash with the one we add
+	records, errorResp := normalizeCSV(strings.NewReader("phoneNumber,countryCode\n631 311 8150,MX\n"), "phoneNumber", "countryCode", ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+	if errorResp != nil {
+		t.Fatalf("Expected no error, got %v", errorResp.Error)
+	}
+
+	columns := csvColumns(records[0])
+	if len(columns) != len(records[0]) {
+		t.Errorf("Expected no duplicate columns, got %q", records[0])
+	}
+	if country := csvField(records[1], columns, "normalized_countryCode"); country != "MX" {
+		t.Errorf("Expected normalized_countryCode MX, got %q", country)
+	}
+}
+
+func TestNormalizeCSVTooManyRows(t *testing.T) {
+	defer func(limit int) { maxBatchItems = limit }(maxBatchItems)
+	maxBatchItems = 2
+
+	input := "phoneNumber\n+12125690123\n+12125690123\n"
+	if _, errorResp := normalizeCSV(strings.NewReader(input), "phoneNumber", "", ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn}); errorResp != nil {
+		t.Errorf("Expected %d rows to be allowed, got %v", maxBatchItems, errorResp.Error)
+	}
+
+	_, errorResp := normalizeCSV(strings.NewReader(input+"+12125690123\n"), "phoneNumber", "", ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn})
+	if errorResp == nil || errorResp.Error["file"] != "too many rows, the maximum is 2" {
+		t.Errorf("Expected too many rows error, got %v", errorResp)
+	}
+}
+
+func TestNormalizedCSVFilename(t *testing.T) {
+	tests := []struct {
+		upload   string
+		expected string
+	}{
+		{"numbers.csv", "numbers-normalized.csv"},
+		{"customer export.CSV", "customer export-normalized.csv"},
+		{"numbers", "numbers-normalized.csv"},
+		{"", "phone-numbers-normalized.csv"},
+	}
+
+	for _, tt := range tests {
+		if filename := normalizedCSVFilename(tt.upload); filename != tt.expected {
+			t.Errorf("normalizedCSVFilename(%q) = %q, expected %q", tt.upload, filename, tt.expected)
+		}
+	}
+}
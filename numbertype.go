@@ -0,0 +1,48 @@
+package main
+
+// NumberType is what kind of line a number belongs to, using libphonenumber's names
+type NumberType string
+
+const (
+	FixedLine         NumberType = "FIXED_LINE"
+	Mobile            NumberType = "MOBILE"
+	FixedLineOrMobile NumberType = "FIXED_LINE_OR_MOBILE" // Some countries (i.e. US) don't tell them apart
+	TollFree          NumberType = "TOLL_FREE"
+	PremiumRate       NumberType = "PREMIUM_RATE"
+	SharedCost        NumberType = "SHARED_COST"
+	Voip              NumberType = "VOIP"
+	PersonalNumber    NumberType = "PERSONAL_NUMBER"
+	Pager             NumberType = "PAGER"
+	Uan               NumberType = "UAN" // Universal access number, i.e. a company's single number
+	Voicemail         NumberType = "VOICEMAIL"
+	UnknownType       NumberType = "UNKNOWN"
+)
+
+// The special service ranges are checked before fixed line and mobile since in a lot of countries
+// the fixed line pattern is broad enough to overlap them
+var specialNumberTypes = []NumberType{PremiumRate, TollFree, SharedCost, Voip, PersonalNumber, Pager, Uan, Voicemail}
+
+// Classifies a valid national number using the region's number type patterns
+func (region *RegionMetadata) numberType(nationalNumber string) NumberType {
+	matches := func(numberType NumberType) bool {
+		pattern, exists := region.numberTypePatterns[numberType]
+		return exists && pattern.MatchString(nationalNumber)
+	}
+
+	for _, numberType := range specialNumberTypes {
+		if matches(numberType) {
+			return numberType
+		}
+	}
+
+	isFixedLine, isMobile := matches(FixedLine), matches(Mobile)
+	switch {
+	case isFixedLine && isMobile:
+		return FixedLineOrMobile
+	case isFixedLine:
+		return FixedLine
+	case isMobile:
+		return Mobile
+	}
+	return UnknownType
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNumberType(t *testing.T) {
+	tests := []struct {
+		name           string
+		countryCode    string
+		nationalNumber string
+		expected       NumberType
+	}{
+		{"US geographic", "US", "2125690123", FixedLineOrMobile},
+		{"US toll free", "US", "8005550123", TollFree},
+		{"US premium rate", "US", "9005550123", PremiumRate},
+		{"US personal number", "US", "5005550123", PersonalNumber},
+		{"Canada geographic", "CA", "4165550123", FixedLineOrMobile},
+		{"Mexico geographic", "MX", "6313118150", FixedLineOrMobile},
+		{"Mexico toll free", "MX", "8001234567", TollFree},
+		{"Spain fixed line", "ES", "915872200", FixedLine},
+		{"Spain mobile", "ES", "612345678", Mobile},
+		{"Spain toll free", "ES", "900123456", TollFree},
+		{"Spain shared cost", "ES", "902123456", SharedCost},
+		{"Spain UAN", "ES", "511234567", Uan},
+		{"Portugal fixed line", "PT", "210942000", FixedLine},
+		{"Portugal mobile", "PT", "912345678", Mobile},
+		{"Portugal VoIP", "PT", "301234567", Voip},
+		{"UK fixed line", "GB", "2079460018", FixedLine},
+		{"UK mobile", "GB", "7400123456", Mobile},
+		{"UK Isle of Man mobile", "GB", "7624123456", Mobile},
+		{"UK pager", "GB", "7640123456", Pager},
+		{"UK personal number", "GB", "7012345678", PersonalNumber},
+		{"UK toll free", "GB", "8001234567", TollFree},
+		{"UK premium rate", "GB", "9012345678", PremiumRate},
+		{"UK shared cost", "GB", "8431234567", SharedCost},
+		{"UK VoIP", "GB", "5612345678", Voip},
+		{"UK UAN", "GB", "3001234567", Uan},
+		{"France fixed line", "FR", "123456789", FixedLine},
+		{"France mobile", "FR", "612345678", Mobile},
+		{"France premium rate", "FR", "891123456", PremiumRate},
+		{"France VoIP", "FR", "912345678", Voip},
+		{"Germany fixed line", "DE", "301234567", FixedLine},
+		{"Germany mobile", "DE", "15123456789", Mobile},
+		{"Germany voicemail", "DE", "171131234567", Voicemail},
+		{"Germany toll free", "DE", "8001234567", TollFree},
+		{"Germany personal number", "DE", "70012345678", PersonalNumber},
+		{"Italy fixed line", "IT", "0212345678", FixedLine},
+		{"Italy mobile", "IT", "3123456789", Mobile},
+		{"Italy voicemail", "IT", "33123456789", Voicemail},
+		{"Italy toll free", "IT", "800123456", TollFree},
+		{"Japan fixed line", "JP", "312345678", FixedLine},
+		{"Japan mobile", "JP", "9012345678", Mobile},
+		{"Japan pager", "JP", "2012345678", Pager},
+		{"Japan toll free", "JP", "120123456", TollFree},
+		{"Japan VoIP", "JP", "5012345678", Voip},
+		{"Japan UAN", "JP", "570123456", Uan},
+		{"Doesn't match anything", "ES", "501234567", UnknownType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, _ := lookupRegion(tt.countryCode)
+			result := region.numberType(tt.nationalNumber)
+			if result != tt.expected {
+				t.Errorf("numberType(%s) = %s, expected %s", tt.nationalNumber, result, tt.expected)
+			}
+		})
+	}
+}
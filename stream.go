@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// StreamItem is one line of a POST /v1/phone-numbers:stream request
+type StreamItem struct {
+	ID          json.RawMessage `json:"id,omitempty"` // Anything the caller wants echoed back to match up the results
+	PhoneNumber string          `json:"phoneNumber"`
+	CountryCode string          `json:"countryCode"`
+}
+
+// StreamResult is one line of the response, Result is a *PhoneNumberResponse or an *ErrorResponse
+type StreamResult struct {
+	Line   int             `json:"line"`
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result any             `json:"result"`
+}
+
+// How many lines can be read ahead of the one being written. Once this many are waiting we stop reading
+// the request until the caller catches up with the response, so memory stays the same however big the upload is
+const streamLinesInFlight = 64
+
+// Longest line we accept, a phone number and country code don't need anywhere near this
+const maxStreamLineLength = 64 * 1024
+
+// Parses newline delimited JSON from the reader and writes a result line for each one as soon as it's ready.
+// Lines are parsed in parallel but the results are written in the same order. Blank lines are skipped.
+// flush is called whenever we've caught up with the parsing so the caller sees results straight away
+func streamPhoneNumbers(reader io.Reader, writer io.Writer, options ParseOptions, flush func()) error {
+	// Each line gets its own channel for its result, queued in order. The queue being full is what stops the reading
+	pending := make(chan chan StreamResult, streamLinesInFlight)
+
+	go func() {
+		defer close(pending)
+
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 4096), maxStreamLineLength)
+
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+
+			result := make(chan StreamResult, 1)
+			pending <- result
+
+			line := append([]byte(nil), scanner.Bytes()...)
+			go func(lineNumber int) {
+				result <- parseStreamLine(lineNumber, line, options)
+			}(lineNumber)
+		}
+
+		// Anything wrong with the body itself (i.e. a line that's too long) ends the stream with one last error
+		if err := scanner.Err(); err != nil {
+			result := make(chan StreamResult, 1)
+			result <- StreamResult{
+				Line: lineNumber + 1,
+				Result: &ErrorResponse{
+					Error: map[string]string{"body": fmt.Sprintf("could not read line: %v", err)},
+				},
+			}
+			pending <- result
+		}
+	}()
+
+	encoder := json.NewEncoder(writer)
+	for result := range pending {
+		if err := encoder.Encode(<-result); err != nil {
+			// The caller has gone, drain the queue so the reader can finish
+			for range pending {
+			}
+			return err
+		}
+		if len(pending) == 0 {
+			flush()
+		}
+	}
+
+	return nil
+}
+
+func parseStreamLine(lineNumber int, line []byte, options ParseOptions) StreamResult {
+	var item StreamItem
+	if err := json.Unmarshal(line, &item); err != nil {
+		return StreamResult{
+			Line: lineNumber,
+			Result: &ErrorResponse{
+				Error: map[string]string{"body": "invalid JSON"},
+			},
+		}
+	}
+
+	return StreamResult{
+		Line:   lineNumber,
+		ID:     item.ID,
+		Result: parseBatchItem(BatchItem{PhoneNumber: item.PhoneNumber, CountryCode: item.CountryCode}, options),
+	}
+}
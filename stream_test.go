@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamPhoneNumbers(t *testing.T) {
+	input := strings.Join([]string{
+		`{"id": "a", "phoneNumber": "+12125690123"}`,
+		``,
+		`{"id": 2, "phoneNumber": "631 311 8150", "countryCode": "MX"}`,
+		`{"phoneNumber": "631 311 8150"}`,
+		`not json`,
+		`{"id": {"row": 5}, "phoneNumber": ""}`,
+	}, "\n")
+
+	var output bytes.Buffer
+	options := ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn}
+	if err := streamPhoneNumbers(strings.NewReader(input), &output, options, func() {}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []struct {
+		line        int
+		id          string
+		phoneNumber string
+		errorKey    string
+		errorValue  string
+	}{
+		{1, `"a"`, "+12125690123", "", ""},
+		{3, `2`, "+526313118150", "", ""},
+		{4, ``, "631 311 8150", "countryCode", "required value is missing"},
+		{5, ``, "", "body", "invalid JSON"},
+		{6, `{"row":5}`, "", "phoneNumber", "required value is missing"},
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), output.String())
+	}
+
+	for i, want := range expected {
+		var result struct {
+			Line   int             `json:"line"`
+			ID     json.RawMessage `json:"id"`
+			Result struct {
+				PhoneNumber string            `json:"phoneNumber"`
+				Error       map[string]string `json:"error"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &result); err != nil {
+			t.Fatalf("Line %d isn't JSON: %v", i+1, err)
+		}
+
+		if result.Line != want.line || string(result.ID) != want.id || result.Result.PhoneNumber != want.phoneNumber {
+			t.Errorf("Expected line %d id %s phoneNumber %s, got %s", want.line, want.id, want.phoneNumber, lines[i])
+		}
+		if want.errorKey != "" && result.Result.Error[want.errorKey] != want.errorValue {
+			t.Errorf("Expected error %s: %s, got %s", want.errorKey, want.errorValue, lines[i])
+		}
+	}
+}
+
+func TestStreamPhoneNumbersLineTooLong(t *testing.T) {
+	input := `{"phoneNumber": "+12125690123"}` + "\n" + strings.Repeat("1", maxStreamLineLength+1)
+
+	var output bytes.Buffer
+	options := ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn}
+	if err := streamPhoneNumbers(strings.NewReader(input), &output, options, func() {}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"line":2`) || !strings.Contains(lines[1], "could not read line") {
+		t.Errorf("Expected a result and a read error, got:\n%s", output.String())
+	}
+}
+
+// Hands out one numbered line per read and counts how many it has given out
+type lineGenerator struct {
+	mu    sync.Mutex
+	read  int
+	total int
+}
+
+func (generator *lineGenerator) Read(buffer []byte) (int, error) {
+	generator.mu.Lock()
+	defer generator.mu.Unlock()
+	if generator.read == generator.total {
+		return 0, io.EOF
+	}
+	generator.read++
+	return copy(buffer, fmt.Sprintf(`{"id": %d, "phoneNumber": "+12125690123"}`+"\n", generator.read)), nil
+}
+
+func (generator *lineGenerator) lines() int {
+	generator.mu.Lock()
+	defer generator.mu.Unlock()
+	return generator.read
+}
+
+// Blocks every write until it's released
+type blockedWriter struct {
+	release chan struct{}
+	output  bytes.Buffer
+}
+
+func (writer *blockedWriter) Write(data []byte) (int, error) {
+	<-writer.release
+	return writer.output.Write(data)
+}
+
+func TestStreamPhoneNumbersBackpressure(t *testing.T) {
+	reader := &lineGenerator{total: 1000}
+	writer := &blockedWriter{release: make(chan struct{})}
+
+	done := make(chan error)
+	go func() {
+		done <- streamPhoneNumbers(reader, writer, ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn}, func() {})
+	}()
+
+	// Nothing is being written so we should stop reading once the queue is full
+	time.Sleep(100 * time.Millisecond)
+	if read := reader.lines(); read > streamLinesInFlight+2 {
+		t.Errorf("Expected reading to stop at about %d lines, read %d", streamLinesInFlight, read)
+	}
+
+	close(writer.release)
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(writer.output.String()), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("Expected 1000 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, fmt.Sprintf(`{"line":%d,"id":%d,`, i+1, i+1)) {
+			t.Fatalf("Expected line %d in order, got %s", i+1, line)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// telURI is a phone number written as an RFC 3966 URI i.e. tel:+1-212-569-0123;ext=42
+// See: https://www.rfc-editor.org/rfc/rfc3966
+type telURI struct {
+	Number     string // The number with the visual separators removed, with a + if it's global
+	Extension  string
+	SubAddress string // ISDN sub-address from the isub parameter
+}
+
+// Visual separators allowed between the digits of a tel: URI
+var telVisualSeparators = regexp.MustCompile(`[-.()]`)
+
+// Digits of a global number or phone-context, after the separators are removed
+var telGlobalDigits = regexp.MustCompile(`^\+[0-9]+$`)
+
+var telDigits = regexp.MustCompile(`^[0-9]+$`)
+
+func isTelURI(phoneNumber string) bool {
+	return len(phoneNumber) >= 4 && strings.EqualFold(phoneNumber[:4], "tel:")
+}
+
+// Parses a tel: URI. Local numbers (without a +) are put together with their phone-context when it's a global
+// number prefix, i.e. tel:5690123;phone-context=+1212 is +12125690123. A domain name phone-context tells us nothing
+// about the country so those are left as national numbers. Returns the error message if the URI is invalid
+func parseTelURI(uri string) (telURI, string) {
+	parts := strings.Split(uri[4:], ";")
+	number := telVisualSeparators.ReplaceAllString(parts[0], "")
+
+	var result telURI
+	var phoneContext string
+	for _, parameter := range parts[1:] {
+		name, value, _ := strings.Cut(parameter, "=")
+		value, err := url.PathUnescape(value)
+		if err != nil {
+			return result, "invalid tel URI"
+		}
+
+		switch strings.ToLower(name) {
+		case "ext":
+			result.Extension = telVisualSeparators.ReplaceAllString(value, "")
+			if !telDigits.MatchString(result.Extension) {
+				return result, "invalid extension"
+			}
+		case "isub":
+			result.SubAddress = value
+		case "phone-context":
+			phoneContext = telVisualSeparators.ReplaceAllString(value, "")
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		if !telGlobalDigits.MatchString(number) {
+			return result, "invalid tel URI"
+		}
+		result.Number = number
+	case phoneContext == "":
+		return result, "missing phone-context"
+	case !telDigits.MatchString(number):
+		return result, "invalid tel URI"
+	case strings.HasPrefix(phoneContext, "+"):
+		if !telGlobalDigits.MatchString(phoneContext) {
+			return result, "invalid phone-context"
+		}
+		result.Number = phoneContext + number
+	default:
+		result.Number = number
+	}
+
+	return result, ""
+}
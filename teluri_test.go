@@ -0,0 +1,128 @@
+package main
+
+import "testing"
+
+func TestIsTelURI(t *testing.T) {
+	tests := []struct {
+		phoneNumber string
+		expected    bool
+	}{
+		{"tel:+1-212-569-0123", true},
+		{"TEL:+12125690123", true},
+		{"+12125690123", false},
+		{"tel", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phoneNumber, func(t *testing.T) {
+			if result := isTelURI(tt.phoneNumber); result != tt.expected {
+				t.Errorf("isTelURI(%s) = %v, expected %v", tt.phoneNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTelURI(t *testing.T) {
+	tests := []struct {
+		name          string
+		uri           string
+		expected      telURI
+		expectedError string
+	}{
+		{"Global number", "tel:+1-212-569-0123", telURI{Number: "+12125690123"}, ""},
+		{"Global number with extension", "tel:+1-212-569-0123;ext=42", telURI{Number: "+12125690123", Extension: "42"}, ""},
+		{"Global number with sub-address", "tel:+1-212-569-0123;isub=1411", telURI{Number: "+12125690123", SubAddress: "1411"}, ""},
+		{"Escaped sub-address", "tel:+12125690123;isub=a%2Fb", telURI{Number: "+12125690123", SubAddress: "a/b"}, ""},
+		{"Parameter names are case insensitive", "tel:+12125690123;EXT=42", telURI{Number: "+12125690123", Extension: "42"}, ""},
+		{"Unknown parameters are ignored", "tel:+12125690123;foo=bar", telURI{Number: "+12125690123"}, ""},
+		{"Local number with global phone-context", "tel:5690123;phone-context=+1212", telURI{Number: "+12125690123"}, ""},
+		{"Phone-context with separators", "tel:569-0123;phone-context=+1-212", telURI{Number: "+12125690123"}, ""},
+		{"Local number with domain phone-context", "tel:2125690123;phone-context=example.com", telURI{Number: "2125690123"}, ""},
+		{"Local number without phone-context", "tel:5690123", telURI{}, "missing phone-context"},
+		{"Letters in number", "tel:+1-212-FLOWERS", telURI{}, "invalid tel URI"},
+		{"Empty number", "tel:", telURI{}, "missing phone-context"},
+		{"Invalid extension", "tel:+12125690123;ext=abc", telURI{}, "invalid extension"},
+		{"Invalid phone-context", "tel:5690123;phone-context=+1a", telURI{}, "invalid phone-context"},
+		{"Invalid escape", "tel:+12125690123;isub=%zz", telURI{}, "invalid tel URI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, message := parseTelURI(tt.uri)
+			if message != tt.expectedError {
+				t.Fatalf("parseTelURI(%s) error = %q, expected %q", tt.uri, message, tt.expectedError)
+			}
+			if message == "" && result != tt.expected {
+				t.Errorf("parseTelURI(%s) = %+v, expected %+v", tt.uri, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePhoneNumberTelURI(t *testing.T) {
+	tests := []struct {
+		name               string
+		phoneNumber        string
+		countryCode        string
+		expectedNumber     string
+		expectedExtension  string
+		expectedSubAddress string
+		expectedRFC3966    string
+	}{
+		{
+			name:              "Global number with extension",
+			phoneNumber:       "tel:+1-212-569-0123;ext=42",
+			expectedNumber:    "+12125690123",
+			expectedExtension: "42",
+			expectedRFC3966:   "tel:+1-212-569-0123;ext=42",
+		},
+		{
+			name:            "Local number with phone-context",
+			phoneNumber:     "tel:5690123;phone-context=+1212",
+			expectedNumber:  "+12125690123",
+			expectedRFC3966: "tel:+1-212-569-0123",
+		},
+		{
+			name:            "Domain phone-context uses the country code",
+			phoneNumber:     "tel:020-7946-0018;phone-context=example.co.uk",
+			countryCode:     "GB",
+			expectedNumber:  "+442079460018",
+			expectedRFC3966: "tel:+44-20-7946-0018",
+		},
+		{
+			name:               "Sub-address",
+			phoneNumber:        "tel:+44-20-7946-0018;isub=1411",
+			expectedNumber:     "+442079460018",
+			expectedSubAddress: "1411",
+			expectedRFC3966:    "tel:+44-20-7946-0018;isub=1411",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errorResp := parsePhoneNumber(tt.phoneNumber, tt.countryCode)
+			if errorResp != nil {
+				t.Fatalf("Expected no error but got %v", errorResp.Error)
+			}
+			if result.PhoneNumber != tt.expectedNumber {
+				t.Errorf("Expected phoneNumber %s, got %s", tt.expectedNumber, result.PhoneNumber)
+			}
+			if result.Extension != tt.expectedExtension {
+				t.Errorf("Expected extension %s, got %s", tt.expectedExtension, result.Extension)
+			}
+			if result.SubAddress != tt.expectedSubAddress {
+				t.Errorf("Expected subAddress %s, got %s", tt.expectedSubAddress, result.SubAddress)
+			}
+			if result.Formats.RFC3966 != tt.expectedRFC3966 {
+				t.Errorf("Expected rfc3966 %s, got %s", tt.expectedRFC3966, result.Formats.RFC3966)
+			}
+		})
+	}
+
+	// Errors echo the URI we were given
+	_, errorResp := parsePhoneNumber("tel:5690123", "US")
+	if errorResp == nil || errorResp.Error["phoneNumber"] != "missing phone-context" || errorResp.PhoneNumber != "tel:5690123" {
+		t.Errorf("Expected missing phone-context error, got %v", errorResp)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+// prefixTrie maps digit strings to values and finds the longest stored prefix of a number in a single pass
+type prefixTrie[T any] struct {
+	root trieNode[T]
+}
+
+type trieNode[T any] struct {
+	children [10]*trieNode[T]
+	value    T
+	hasValue bool
+}
+
+func (trie *prefixTrie[T]) insert(prefix string, value T) {
+	node := &trie.root
+	for _, digit := range prefix {
+		if digit < '0' || digit > '9' {
+			return
+		}
+		index := digit - '0'
+		if node.children[index] == nil {
+			node.children[index] = &trieNode[T]{}
+		}
+		node = node.children[index]
+	}
+	node.value = value
+	node.hasValue = true
+}
+
+// Walks the number digit by digit and returns the longest prefix that has a value
+func (trie *prefixTrie[T]) longestPrefix(number string) (string, T, bool) {
+	node := &trie.root
+
+	// The empty prefix matches everything
+	var prefix string
+	value, found := node.value, node.hasValue
+
+	for i, digit := range number {
+		if digit < '0' || digit > '9' {
+			break
+		}
+		node = node.children[digit-'0']
+		if node == nil {
+			break
+		}
+		if node.hasValue {
+			prefix, value, found = number[:i+1], node.value, true
+		}
+	}
+
+	return prefix, value, found
+}
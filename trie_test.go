@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefixTrieLongestPrefix(t *testing.T) {
+	trie := &prefixTrie[string]{}
+	trie.insert("1", "one")
+	trie.insert("12", "twelve")
+	trie.insert("123", "one two three")
+	trie.insert("35", "thirty five")
+
+	tests := []struct {
+		name           string
+		number         string
+		expectedPrefix string
+		expectedValue  string
+		expectedFound  bool
+	}{
+		{"Longest match", "1234", "123", "one two three", true},
+		{"Falls back to shorter match", "1299", "12", "twelve", true},
+		{"Single digit", "19", "1", "one", true},
+		{"Partial path without value", "3", "", "", false},
+		{"No match", "99", "", "", false},
+		{"Stops at non digit", "1a23", "1", "one", true},
+		{"Empty", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, value, found := trie.longestPrefix(tt.number)
+			if prefix != tt.expectedPrefix || value != tt.expectedValue || found != tt.expectedFound {
+				t.Errorf("longestPrefix(%s) = (%s, %s, %v), expected (%s, %s, %v)",
+					tt.number, prefix, value, found, tt.expectedPrefix, tt.expectedValue, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func TestBuildDialCodeTrie(t *testing.T) {
+	trie := buildDialCodeTrie(map[string]*RegionMetadata{
+		"CA": {CountryCode: "CA", DialCode: "1"},
+		"AG": {CountryCode: "AG", DialCode: "1"},
+		"US": {CountryCode: "US", DialCode: "1", MainCountryForCode: true},
+		"PT": {CountryCode: "PT", DialCode: "351"},
+		"ES": {CountryCode: "ES", DialCode: "34"},
+	})
+
+	dialCode, countryCodes, _ := trie.longestPrefix("12125690123")
+	if dialCode != "1" || !reflect.DeepEqual(countryCodes, []string{"US", "AG", "CA"}) {
+		t.Errorf("Expected main country first for 1, got %s %v", dialCode, countryCodes)
+	}
+
+	dialCode, countryCodes, _ = trie.longestPrefix("351210942000")
+	if dialCode != "351" || !reflect.DeepEqual(countryCodes, []string{"PT"}) {
+		t.Errorf("Expected PT for 351, got %s %v", dialCode, countryCodes)
+	}
+
+	if _, _, found := trie.longestPrefix("35"); found {
+		t.Errorf("Expected no match for incomplete dial code")
+	}
+}
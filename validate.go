@@ -5,43 +5,27 @@ import (
 	"strings"
 )
 
-// CountryCodeMap maps country codes to their dial codes
-// I just made a small selection of the entire set for the example
-// See: https://www.internationalinsurance.com/calling-codes/?srsltid=AfmBOoqNh14at9fwXCtDsi71BB1cyLoNY1177PY792OyUdkiyMEQDvE0
-// We use this to identify the country its from and later its area code (see below)
-var CountryCodeMap = map[string]string{
-	"US": "1",   // US
-	"MX": "52",  // Mexico
-	"ES": "34",  // Spain
-	"PT": "351", // Portugal
-	"CA": "1",   // Canada
-	"GB": "44",  // United Kingdom
-	"FR": "33",  // France
-	"DE": "49",  // Germany
-	"IT": "39",  // Italy
-	"JP": "81",  // Japan
-}
-
-// AreaCodeMap maps country codes to their area code lengths
-// Basically it states that in a given country, the area codes in that country have the corresponding link
-// We need to know this for parsing
-var AreaCodeMap = map[string]int{
-	"US": 3, // US i.e 212 for Manhattan
-	"MX": 3, // Mexico
-	"ES": 3, // Spain
-	"PT": 2, // Portugal
-	"CA": 3, // Canada
-	"GB": 4, // United Kingdom
-	"FR": 1, // France
-	"DE": 3, // Germany
-	"IT": 3, // Italy
-	"JP": 1, // Japan
-}
+// International call prefixes we recognise when we don't know which country the caller is dialling from
+// Longer prefixes go first so 0011 isn't read as 00 followed by 11
+var commonInternationalPrefixes = []string{"0011", "011", "010", "810", "00"}
 
 func cleanNumber(phoneNumber string) string {
 	return strings.ReplaceAll(strings.TrimPrefix(phoneNumber, "+"), " ", "")
 }
 
+// Separators people commonly put in numbers i.e. (212) 569-0123, 212.569.0123 or 030/1234567
+const separators = "()./-\t\n\f\r "
+
+// Removes all separators, used for lenient mode
+func stripSeparators(phoneNumber string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(separators, r) {
+			return -1
+		}
+		return r
+	}, phoneNumber)
+}
+
 func stripPlus(phoneNumber string) string {
 	return strings.TrimPrefix(phoneNumber, "+")
 }
@@ -60,6 +44,12 @@ func validateCountryCodeMeetsISO_3166_1_alpha_2(countryCode string) bool {
 	return true
 }
 
+// Compiled once, they're checked for every number we parse
+var (
+	digitsAndSpaces = regexp.MustCompile(`^[0-9 ]+$`)
+	digitsOnly      = regexp.MustCompile(`^[0-9]+$`)
+)
+
 func validatePhoneNumberFormat(phoneNumber string) bool {
 	// Remove + if present for easier parsing
 	cleanNumber := stripPlus(phoneNumber)
@@ -70,8 +60,7 @@ func validatePhoneNumberFormat(phoneNumber string) bool {
 	}
 
 	// Check if it contains only digits and spaces
-	validChars := regexp.MustCompile(`^[0-9 ]+$`)
-	return validChars.MatchString(cleanNumber)
+	return digitsAndSpaces.MatchString(cleanNumber)
 }
 
 // Checks if spaces are in valid positions: between country, area, and local only
@@ -89,44 +78,197 @@ func validateSpaces(phoneNumber string) bool {
 		return false
 	}
 
-	// Split by spaces and check each part contains only digits
+	// Split by spaces and check each part contains only digits. Where the spaces fall is checked by
+	// validateSpaceBoundaries once we know the country code and area code
 	parts := strings.Split(cleanNumber, " ")
 	for _, part := range parts {
 		if part == "" {
 			return false
 		}
-		if !regexp.MustCompile(`^[0-9]+$`).MatchString(part) {
+		if !digitsOnly.MatchString(part) {
 			return false
 		}
 	}
 
-	// For numbers with spaces, we should have at most 3 parts: country code, area code, local number
-	return len(parts) <= 3
+	return true
+}
+
+// interpretation is one way of reading the digits of a phone number
+type interpretation struct {
+	countryCode    string
+	dialCode       string
+	nationalNumber string
+	international  bool // The dial code is part of the number
+	valid          bool // The national number is valid for the country
+}
+
+func (reading interpretation) e164() string {
+	return "+" + reading.dialCode + reading.nationalNumber
+}
+
+// Lists the ways the number could be read. A number with a + always carries its own country code, but plain
+// digits could be international (34 915 872200) or national for the countryCode we were given (1632 960001 in GB).
+// Valid readings come first, and when both are valid the national one wins since the caller told us the country.
+// When neither is valid the international reading goes first like it always has
+func findInterpretations(phoneNumber, countryCode string) []interpretation {
+	var interpretations []interpretation
+	cleanNumber := cleanNumber(phoneNumber)
+
+	if country, dialCode, found := extractCountryCodeFromNumber(phoneNumber); found {
+		reading := interpretation{
+			countryCode:    country,
+			dialCode:       dialCode,
+			nationalNumber: cleanNumber[len(dialCode):],
+			international:  true,
+		}
+		if region, exists := lookupRegion(country); exists {
+			reading.valid = region.validateNationalNumber(reading.nationalNumber) == ""
+		}
+		interpretations = append(interpretations, reading)
+	}
+
+	if strings.HasPrefix(phoneNumber, "+") || !validateCountryCodeMeetsISO_3166_1_alpha_2(countryCode) {
+		return interpretations
+	}
+	region, exists := lookupRegion(countryCode)
+	if !exists {
+		return interpretations
+	}
+
+	national := interpretation{
+		countryCode:    region.CountryCode,
+		dialCode:       region.DialCode,
+		nationalNumber: region.stripTrunkPrefix(cleanNumber),
+	}
+	national.valid = region.validateNationalNumber(national.nationalNumber) == ""
+
+	// NANP countries share the dial code, so the area code says which one the number is really in the same way it
+	// does for +1 numbers, i.e. 416 555 0123 is in Canada even if we were told US
+	if national.valid && region.DialCode == "1" {
+		national.countryCode = resolveNationalNANPCountry(national.nationalNumber, region.CountryCode)
+	}
+
+	switch {
+	case len(interpretations) > 0 && interpretations[0].e164() == national.e164():
+		// i.e. 1 212 569 0123 for the US, both readings are the same number
+	case national.valid:
+		interpretations = append([]interpretation{national}, interpretations...)
+	default:
+		interpretations = append(interpretations, national)
+	}
+
+	return interpretations
+}
+
+// Picks the country for a national NANP number the same way as for a +1 number, keeping the country we were given
+// if the number isn't valid there
+func resolveNationalNANPCountry(nationalNumber, countryCode string) string {
+	_, countryCodes, found := dialCodeTrie.longestPrefix("1")
+	if !found {
+		return countryCode
+	}
+
+	country := resolveNANPCountry(nationalNumber, countryCodes)
+	if region, exists := lookupRegion(country); !exists || region.validateNationalNumber(nationalNumber) != "" {
+		return countryCode
+	}
+	return country
+}
+
+// Checks the spaces in the number fall between the country code, area code and local number of the parsed result.
+// Numbers can also be spaced the way the country writes them, i.e. 212 569 0123, 020 7946 0018 or +44 20 7946 0018.
+// Returns the part a misplaced space is in, or "" if they're all fine
+func validateSpaceBoundaries(phoneNumber string, result *PhoneNumberResponse, hasCountryCodeInNumber bool) string {
+	region, exists := lookupRegion(result.CountryCode)
+	if !exists {
+		return ""
+	}
+
+	nationalNumber := strings.TrimPrefix(result.PhoneNumber, "+"+region.DialCode)
+
+	// Whatever comes before the national number, the dial code or a trunk prefix
+	prefixLength := len(cleanNumber(phoneNumber)) - len(nationalNumber)
+	areaCodeEnd := prefixLength + len(result.AreaCode)
+
+	boundaries := map[int]bool{areaCodeEnd: true}
+	if hasCountryCodeInNumber {
+		boundaries[prefixLength] = true
+	}
+	if format := region.formatFor(nationalNumber); format != nil {
+		groupEnd := prefixLength
+		for _, group := range format.pattern.FindStringSubmatch(nationalNumber)[1:] {
+			groupEnd += len(group)
+			boundaries[groupEnd] = true
+		}
+	}
+
+	digits := 0
+	for _, char := range stripPlus(phoneNumber) {
+		if char != ' ' {
+			digits++
+			continue
+		}
+		if boundaries[digits] {
+			continue
+		}
+
+		switch {
+		case hasCountryCodeInNumber && digits < prefixLength:
+			return "country code"
+		case digits < areaCodeEnd:
+			return "area code"
+		default:
+			return "local phone number"
+		}
+	}
+
+	return ""
 }
 
+// Resolves the dial code at the start of the number using the dial code trie
+// When several regions share the dial code we return the main country for it, except for
+// +1 where the area code tells us which NANP country it is
 func extractCountryCodeFromNumber(phoneNumber string) (string, string, bool) {
-    cleanNumber := cleanNumber(phoneNumber)
-
-	// There is a wacky edge case where we want to prefer US for 1 instead of Canada
-    
-    // Check dial codes in order of length (longest first)
-	// This is a hacky way to do it to save time
-	// If I had more time I could figure out how to sort the map
-    dialCodes := []string{"351", "52", "44", "49", "39", "81", "34", "33", "1"}
-    
-    for _, dialCode := range dialCodes {
-        if strings.HasPrefix(cleanNumber, dialCode) {
-            if dialCode == "1" {
-                return "US", dialCode, true
-            }
-            // Find the country for this dial code
-            for country, code := range CountryCodeMap {
-                if code == dialCode {
-                    return country, dialCode, true
-                }
-            }
-        }
-    }
-    
-    return "", "", false
-}
\ No newline at end of file
+	cleanNumber := cleanNumber(phoneNumber)
+
+	dialCode, countryCodes, found := dialCodeTrie.longestPrefix(cleanNumber)
+	if !found {
+		return "", "", false
+	}
+
+	if dialCode == "1" {
+		return resolveNANPCountry(cleanNumber[len(dialCode):], countryCodes), dialCode, true
+	}
+
+	return countryCodes[0], dialCode, true
+}
+
+// Rewrites numbers dialled with an international call prefix (i.e. 011 52 631 3118150 from the US)
+// into the + form so the rest of the parsing treats what follows as the dial code.
+// If we know the caller's country we only accept its prefix, otherwise we try the common ones.
+// The prefix only counts if it's followed by a dial code we know about
+func stripInternationalPrefix(phoneNumber, countryCode string) (string, bool) {
+	if strings.HasPrefix(phoneNumber, "+") {
+		return phoneNumber, false
+	}
+
+	var prefixes []string
+	if region, exists := lookupRegion(countryCode); exists && region.internationalPrefix != nil {
+		prefixes = []string{region.internationalPrefix.FindString(phoneNumber)}
+	} else {
+		prefixes = commonInternationalPrefixes
+	}
+
+	for _, prefix := range prefixes {
+		if prefix == "" || !strings.HasPrefix(phoneNumber, prefix) {
+			continue
+		}
+
+		remainingNumber := strings.TrimPrefix(phoneNumber[len(prefix):], " ")
+		if _, _, found := dialCodeTrie.longestPrefix(remainingNumber); found {
+			return "+" + remainingNumber, true
+		}
+	}
+
+	return phoneNumber, false
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -73,14 +74,14 @@ func TestValidateSpaces(t *testing.T) {
 		{"Valid single space", "1 2125690123", true},
 		{"Valid two spaces", "1 212 5690123", true},
 		{"Valid three parts", "1 212 569", true},
-		{"Invalid four parts", "1 212 569 0123", false},
+		{"Valid four parts", "1 212 569 0123", true},
 		{"Invalid consecutive spaces", "1  212 5690123", false},
 		{"Invalid leading space", " 1 212 5690123", false},
 		{"Invalid trailing space", "1 212 5690123 ", false},
 		{"Invalid empty part", "1  212", false},
 		{"Invalid non-digit in part", "1 abc 212", false},
 		{"Valid with + and spaces", "+1 212 5690123", true},
-		{"Invalid with + and four parts", "+1 212 569 0123", false},
+		{"Valid with + and four parts", "+1 212 569 0123", true},
 		{"Valid edge case - single digit parts", "1 2 3", true},
 		{"Valid edge case - country code only", "1", true},
 	}
@@ -116,6 +117,14 @@ func TestExtractCountryCodeFromNumber(t *testing.T) {
 		{"Number without country code", "2125690123", "", "", false},
 		{"Germany number", "+49301234567", "DE", "49", true},
 		{"Japan number", "+81312345678", "JP", "81", true},
+		{"France number", "+33612345678", "FR", "33", true},
+		{"Canada number", "+14165550123", "CA", "1", true},
+		{"Jamaica number", "+18765550123", "JM", "1", true},
+		{"Puerto Rico number", "+17875550123", "PR", "1", true},
+		{"Dominican Republic number", "+1 829 5550123", "DO", "1", true},
+		{"US toll free number", "+18005550123", "US", "1", true},
+		{"NANP number without area code", "+1", "US", "1", true},
+		{"Italy number", "+390212345678", "IT", "39", true},
 	}
 
 	for _, tt := range tests {
@@ -129,6 +138,36 @@ func TestExtractCountryCodeFromNumber(t *testing.T) {
 	}
 }
 
+func TestFindInterpretations(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		countryCode string
+		expected    []string // E.164 of each reading in order
+	}{
+		{"Plus sign is always international", "+4930123456", "DE", []string{"+4930123456"}},
+		{"No country code given", "34915872200", "", []string{"+34915872200"}},
+		{"National reading is valid", "1632960001", "GB", []string{"+441632960001", "+1632960001"}},
+		{"Only international reading is valid", "34915872200", "US", []string{"+34915872200", "+134915872200"}},
+		{"Both readings are valid", "4930123456", "DE", []string{"+494930123456", "+4930123456"}},
+		{"Both readings are the same number", "12125690123", "US", []string{"+12125690123"}},
+		{"Only a national reading", "2125690123", "US", []string{"+12125690123"}},
+		{"Unsupported country code", "2125690123", "ZZ", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for _, reading := range findInterpretations(tt.phoneNumber, tt.countryCode) {
+				result = append(result, reading.e164())
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("findInterpretations(%s, %s) = %v, expected %v", tt.phoneNumber, tt.countryCode, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestCleanNumber(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -178,3 +217,99 @@ func TestStripPlus(t *testing.T) {
 		})
 	}
 }
+
+func TestStripInternationalPrefix(t *testing.T) {
+	tests := []struct {
+		name          string
+		phoneNumber   string
+		countryCode   string
+		expected      string
+		expectedFound bool
+	}{
+		{"00 without country", "0034915872200", "", "+34915872200", true},
+		{"00 with space", "0034 915 872200", "", "+34 915 872200", true},
+		{"011 with spaces", "011 52 631 3118150", "", "+52 631 3118150", true},
+		{"0011 not read as 00", "0011 44 2079460018", "", "+44 2079460018", true},
+		{"810 from Russia", "81044 2079460018", "", "+44 2079460018", true},
+		{"011 from the US", "011 52 631 3118150", "US", "+52 631 3118150", true},
+		{"00 from Spain", "0052 631 3118150", "ES", "+52 631 3118150", true},
+		{"010 from Japan", "010 1 212 5690123", "JP", "+1 212 5690123", true},
+		{"Wrong prefix for the country", "011 52 631 3118150", "ES", "011 52 631 3118150", false},
+		{"Already has +", "+34915872200", "", "+34915872200", false},
+		{"No dial code after prefix", "00999123456", "", "00999123456", false},
+		{"National number", "2125690123", "US", "2125690123", false},
+		{"UK national number", "02079460018", "GB", "02079460018", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, found := stripInternationalPrefix(tt.phoneNumber, tt.countryCode)
+			if result != tt.expected || found != tt.expectedFound {
+				t.Errorf("stripInternationalPrefix(%s, %s) = (%s, %v), expected (%s, %v)",
+					tt.phoneNumber, tt.countryCode, result, found, tt.expected, tt.expectedFound)
+			}
+		})
+	}
+}
+
+func TestStripSeparators(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		expected    string
+	}{
+		{"Parentheses and dash", "(212) 569-0123", "2125690123"},
+		{"Dots", "212.569.0123", "2125690123"},
+		{"Slash", "030/1234567", "0301234567"},
+		{"Plus is kept", "+1 (212) 569-0123", "+12125690123"},
+		{"Letters are kept", "212-ABC-0123", "212ABC0123"},
+		{"Nothing to strip", "2125690123", "2125690123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := stripSeparators(tt.phoneNumber)
+			if result != tt.expected {
+				t.Errorf("stripSeparators(%s) = %s, expected %s", tt.phoneNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateSpaceBoundaries(t *testing.T) {
+	us := &PhoneNumberResponse{PhoneNumber: "+12125690123", CountryCode: "US", AreaCode: "212"}
+	gb := &PhoneNumberResponse{PhoneNumber: "+442079460018", CountryCode: "GB", AreaCode: "20"}
+
+	tests := []struct {
+		name                   string
+		phoneNumber            string
+		result                 *PhoneNumberResponse
+		hasCountryCodeInNumber bool
+		expected               string
+	}{
+		{"No spaces", "+12125690123", us, true, ""},
+		{"Country, area and local", "+1 212 5690123", us, true, ""},
+		{"Only after country code", "+1 2125690123", us, true, ""},
+		{"Only after area code", "+1212 5690123", us, true, ""},
+		{"Space inside area code", "+12 125690123", us, true, "area code"},
+		{"Space inside local number", "+1212 5690 123", us, true, "local phone number"},
+		{"International written the US way", "+1 212 569 0123", us, true, ""},
+		{"International written the GB way", "+44 20 7946 0018", gb, true, ""},
+		{"Space inside country code", "+4 42079460018", gb, true, "country code"},
+		{"National area and local", "212 5690123", us, false, ""},
+		{"National written the US way", "212 569 0123", us, false, ""},
+		{"National space inside area code", "21 25690123", us, false, "area code"},
+		{"National space in the wrong place", "212 5690 123", us, false, "local phone number"},
+		{"National with trunk prefix", "020 7946 0018", gb, false, ""},
+		{"National space after trunk prefix", "0 2079460018", gb, false, "area code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateSpaceBoundaries(tt.phoneNumber, tt.result, tt.hasCountryCodeInNumber)
+			if result != tt.expected {
+				t.Errorf("validateSpaceBoundaries(%s) = %q, expected %q", tt.phoneNumber, result, tt.expected)
+			}
+		})
+	}
+}
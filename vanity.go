@@ -0,0 +1,49 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Letters on each key of a phone keypad
+var keypadLetters = map[rune]string{
+	'2': "ABC",
+	'3': "DEF",
+	'4': "GHI",
+	'5': "JKL",
+	'6': "MNO",
+	'7': "PQRS",
+	'8': "TUV",
+	'9': "WXYZ",
+}
+
+// keypadDigits maps each letter to the key it's on, built from keypadLetters
+var keypadDigits = buildKeypadDigits()
+
+var vanityLetters = regexp.MustCompile(`[A-Za-z]`)
+
+func buildKeypadDigits() map[rune]rune {
+	digits := make(map[rune]rune)
+	for digit, letters := range keypadLetters {
+		for _, letter := range letters {
+			digits[letter] = digit
+		}
+	}
+	return digits
+}
+
+// Whether the number is spelled out with letters i.e. 1-800-FLOWERS
+func isVanityNumber(phoneNumber string) bool {
+	return vanityLetters.MatchString(phoneNumber)
+}
+
+// Swaps every letter for the digit on its key, 1-800-FLOWERS becomes 1-800-3569377
+func convertVanityNumber(phoneNumber string) string {
+	return strings.Map(func(r rune) rune {
+		if digit, exists := keypadDigits[unicode.ToUpper(r)]; exists {
+			return digit
+		}
+		return r
+	}, phoneNumber)
+}
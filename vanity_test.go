@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestIsVanityNumber(t *testing.T) {
+	tests := []struct {
+		phoneNumber string
+		expected    bool
+	}{
+		{"1-800-FLOWERS", true},
+		{"1-800-flowers", true},
+		{"+1 800 3569377", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phoneNumber, func(t *testing.T) {
+			if result := isVanityNumber(tt.phoneNumber); result != tt.expected {
+				t.Errorf("isVanityNumber(%s) = %v, expected %v", tt.phoneNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertVanityNumber(t *testing.T) {
+	tests := []struct {
+		phoneNumber string
+		expected    string
+	}{
+		{"1-800-FLOWERS", "1-800-3569377"},
+		{"1-800-flowers", "1-800-3569377"},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZ", "22233344455566677778889999"},
+		{"+1 800 3569377", "+1 800 3569377"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phoneNumber, func(t *testing.T) {
+			if result := convertVanityNumber(tt.phoneNumber); result != tt.expected {
+				t.Errorf("convertVanityNumber(%s) = %s, expected %s", tt.phoneNumber, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePhoneNumberVanity(t *testing.T) {
+	options := ParseOptions{Mode: ModeStrict, CountryCodeConflict: ConflictWarn, AllowVanity: true}
+
+	result, errorResp := parsePhoneNumberWithOptions("1-800-FLOWERS", "", options)
+	if errorResp != nil {
+		t.Fatalf("Expected no error but got %v", errorResp.Error)
+	}
+	if result.PhoneNumber != "+18003569377" || result.VanityNumber != "1-800-FLOWERS" || result.NumberType != string(TollFree) {
+		t.Errorf("Expected +18003569377 TOLL_FREE from 1-800-FLOWERS, got %s %s from %s", result.PhoneNumber, result.NumberType, result.VanityNumber)
+	}
+
+	// Numbers without letters are left alone
+	result, errorResp = parsePhoneNumberWithOptions("+1 800 3569377", "", options)
+	if errorResp != nil {
+		t.Fatalf("Expected no error but got %v", errorResp.Error)
+	}
+	if result.VanityNumber != "" {
+		t.Errorf("Expected no vanityNumber, got %s", result.VanityNumber)
+	}
+
+	// Letters are still invalid unless vanity numbers are allowed
+	options.AllowVanity = false
+	if _, errorResp := parsePhoneNumberWithOptions("1-800-FLOWERS", "", options); errorResp == nil || errorResp.Error["phoneNumber"] != "invalid format" {
+		t.Errorf("Expected invalid format error, got %v", errorResp)
+	}
+}